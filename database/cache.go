@@ -49,6 +49,7 @@ var cache struct {
 	wordsMap     map[string]wordsMapT
 	userSlice    []UserT
 	voteSlice    [][]VoteT
+	rateLimitMap map[string]RateLimitT
 }
 
 /* -------------------------------------------------------------------------- */
@@ -65,7 +66,7 @@ func unsafeLoadCache() error {
 	log.Print("Filling cache from database...")
 
 	// Verify connection to database
-	err = database.Ping()
+	err = store.Ping()
 	if err != nil {
 		return errors.New("unsafeLoadCache: pinging database failed" + err.Error())
 	}
@@ -73,13 +74,7 @@ func unsafeLoadCache() error {
 	/* --------------------------------- QUOTES --------------------------------- */
 
 	// get all quotes from database
-	rows, err := database.Query(`SELECT
-		QuoteID,
-		TeacherID,
-		Context,
-		Text,
-		Unixtime FROM quotes`)
-
+	quotes, err := store.GetQuotes()
 	if err != nil {
 		return errors.New("unsafeLoadCache: loading quotes from database failed: " + err.Error())
 	}
@@ -88,14 +83,7 @@ func unsafeLoadCache() error {
 	cache.wordsMap = make(map[string]wordsMapT)
 
 	// Iterrate over all quotes from database
-	for rows.Next() {
-		// Get id and text of quote
-		var q QuoteT
-		err = rows.Scan(&q.QuoteID, &q.TeacherID, &q.Context, &q.Text, &q.Unixtime)
-		if err != nil {
-			return errors.New("unsafeLoadCache: parsing quotes failed: " + err.Error())
-		}
-
+	for _, q := range quotes {
 		// add to local database
 		// unsafe, because cache is already locked for writing
 		err = unsafeAddQuoteToCache(q)
@@ -104,91 +92,46 @@ func unsafeLoadCache() error {
 		}
 	}
 
-	rows.Close()
-
 	/* -------------------------------- TEACHERS -------------------------------- */
 
 	// get all teachers from database
-	rows, err = database.Query(`SELECT
-		TeacherID,
-		Name,
-		Title,
-		Note FROM teachers`)
-
+	teachers, err := store.GetTeachers()
 	if err != nil {
 		return errors.New("unsafeLoadCache: loading teachers from database failed: " + err.Error())
 	}
 
 	// Iterate over all teachers from database
-	for rows.Next() {
-		// Get teacher data (id, name, title, note)
-		var t TeacherT
-		err = rows.Scan(&t.TeacherID, &t.Name, &t.Title, &t.Note)
-		if err != nil {
-			return errors.New("unsafeLoadCache: parsing teachers failed: " + err.Error())
-		}
-
+	for _, t := range teachers {
 		// add to local database
 		// unsafe, because cache is already locked for writing
 		unsafeAddTeacherToCache(t)
 	}
 
-	rows.Close()
-
 	/* ---------------------------------- USERS --------------------------------- */
 
 	// get all users from database
-	rows, err = database.Query(`SELECT
-		UserID,
-		Name,
-		Password,
-		Admin FROM users`)
-
+	users, err := store.GetUsers()
 	if err != nil {
 		return errors.New("unsafeLoadCache: loading users from database failed: " + err.Error())
 	}
 
 	// Iterrate over all users from database
-	for rows.Next() {
-		// Get user data (id, name, password admin)
-		var u UserT
-		err = rows.Scan(&u.UserID, &u.Name, &u.Password, &u.Admin)
-		if err != nil {
-			return errors.New("unsafeLoadCache: parsing users failed: " + err.Error())
-		}
-
+	for _, u := range users {
 		// add to local database
 		// unsafe, because cache is already locked for writing
 		unsafeAddUserToCache(u)
-		if err != nil {
-			return errors.New("unsafeLoadCache: adding user to cache failed: " + err.Error())
-		}
 	}
 
-	rows.Close()
-
 	/* ---------------------------------- VOTES --------------------------------- */
 
 	// get all votes from database
-	rows, err = database.Query(`SELECT
-		UserID,
-		QuoteID,
-		Rating FROM votes`)
-
+	votes, err := store.GetVotes()
 	if err != nil {
 		return errors.New("unsafeLoadCache: loading votes from database failed: " + err.Error())
 	}
 
 	// Iterrate over all votes from database
-	for rows.Next() {
-		// Get vote data (userid, quoteid)
-		var vote VoteT
-
-		err = rows.Scan(&vote.UserID, &vote.QuoteID, &vote.Val)
-		if err != nil {
-			return errors.New("unsafeLoadCache: parsing votes failed: " + err.Error())
-		}
-
+	for _, vote := range votes {
 		// add to local database
 		// unsafe, because cache is already locked for writing
 		_, err = unsafeAddVoteToCache(vote)
@@ -197,7 +140,26 @@ func unsafeLoadCache() error {
 		}
 	}
 
-	rows.Close()
+	/* ------------------------------- RATE LIMITS ------------------------------ */
+
+	// start with the defaults, so that limits missing from the database exist as well
+	cache.rateLimitMap = make(map[string]RateLimitT)
+	for _, l := range defaultRateLimits {
+		cache.rateLimitMap[l.Name] = l
+	}
+
+	// get all changed rate limits from database
+	limits, err := store.GetRateLimits()
+	if err != nil {
+		return errors.New("unsafeLoadCache: loading rate limits from database failed: " + err.Error())
+	}
+
+	// limits which are no longer used are ignored
+	for _, l := range limits {
+		if _, ok := cache.rateLimitMap[l.Name]; ok {
+			cache.rateLimitMap[l.Name] = l
+		}
+	}
 
 	log.Print("Filled cache successfully")
 
@@ -212,6 +174,8 @@ func unsafeClearCache() {
 	cache.teacherSlice = nil
 	cache.wordsMap = nil
 	cache.userSlice = nil
+	cache.voteSlice = nil
+	cache.rateLimitMap = nil
 }
 
 // Just adds quote to cache (quoteSlice and wordsMap) without checking q.QuoteID
@@ -250,6 +214,52 @@ func unsafeAddUserToCache(u UserT) {
 	cache.userSlice = append(cache.userSlice, u)
 }
 
+// unsafe functions aren't concurrency safe
+func unsafeOverwriteUserInCache(u UserT) error {
+	for i, v := range cache.userSlice {
+		if v.UserID == u.UserID {
+			cache.userSlice[i] = u
+			return nil
+		}
+	}
+	return errors.New("unsafeOverwriteUserInCache: could not find specified entry for overwrite")
+}
+
+// unsafeDeleteUserFromCache removes a user and takes back their votes
+// unsafe functions aren't concurrency safe
+func unsafeDeleteUserFromCache(ID int32) error {
+	index := -1
+	for i, u := range cache.userSlice {
+		if u.UserID == ID {
+			index = i
+			break
+		}
+	}
+
+	if index < 0 {
+		return errors.New("unsafeDeleteUserFromCache: could not find entry to delete")
+	}
+
+	cache.userSlice = append(cache.userSlice[:index], cache.userSlice[index+1:]...)
+
+	if ID >= 1 && int(ID) <= len(cache.voteSlice) {
+		for _, vote := range cache.voteSlice[ID-1] {
+			for j, quote := range cache.quoteSlice {
+				if quote.QuoteID == vote.QuoteID {
+					cache.quoteSlice[j].Stats.Data[vote.Val-1]--
+					break
+				}
+			}
+		}
+		cache.voteSlice[ID-1] = nil
+	}
+
+	// the number of users is part of every quote's popularity
+	unsafeRecalculateQuoteStats()
+
+	return nil
+}
+
 // unsafe functions aren't concurrency safe
 func unsafeAddVoteToCache(vote VoteT) (QuoteT, error) {
 	if vote.UserID < 1 {
@@ -303,6 +313,41 @@ func unsafeAddVoteToCache(vote VoteT) (QuoteT, error) {
 	return QuoteT{}, fmt.Errorf("unsafeAddVoteToCache: quote with QuoteID %d doesn't exist (anymore)", vote.QuoteID)
 }
 
+// unsafeDeleteVoteFromCache removes the vote of the user for the quote and
+// returns the quote with updated stats, which are unchanged if there was no such vote
+// unsafe functions aren't concurrency safe
+func unsafeDeleteVoteFromCache(userID int32, quoteID int32) (QuoteT, error) {
+	j := -1
+	for i, quote := range cache.quoteSlice {
+		if quote.QuoteID == quoteID {
+			j = i
+			break
+		}
+	}
+
+	if j < 0 {
+		return QuoteT{}, fmt.Errorf("unsafeDeleteVoteFromCache: quote with QuoteID %d doesn't exist (anymore)", quoteID)
+	}
+
+	if userID < 1 || len(cache.voteSlice) < int(userID) {
+		// the user hasn't voted for anything yet
+		return cache.quoteSlice[j], nil
+	}
+
+	votes := cache.voteSlice[userID-1]
+	for i, vote := range votes {
+		if vote.QuoteID == quoteID {
+			cache.voteSlice[userID-1] = append(votes[:i], votes[i+1:]...)
+			cache.quoteSlice[j].Stats.Data[vote.Val-1]--
+
+			calculateQuoteStats(&cache.quoteSlice[j])
+			break
+		}
+	}
+
+	return cache.quoteSlice[j], nil
+}
+
 func unsafeOverwriteTeacherInCache(t TeacherT) error {
 
 	affected := false
@@ -381,13 +426,10 @@ func unsafeDeleteTeacherFromCache(ID int32) error {
 	quotes := unsafeGetAllQuotesFromCache()
 	for _, q := range quotes {
 		if q.TeacherID == ID {
-			log.Print(q)
 			err := unsafeDeleteQuoteFromCache(q.QuoteID)
 			if err != nil {
 				return errors.New("unsafeDeleteTeacherFromCache: could not delete quote from cache: " + err.Error())
 			}
-		} else {
-			log.Print(q.TeacherID)
 		}
 	}
 
@@ -411,6 +453,19 @@ func unsafeDeleteTeacherFromCache(ID int32) error {
 
 }
 
+// unsafeMergeTeachersInCache assigns the quotes of the teacher with otherID
+// to the teacher with ID and removes the teacher with otherID
+func unsafeMergeTeachersInCache(ID int32, otherID int32) error {
+	for i := range cache.quoteSlice {
+		if cache.quoteSlice[i].TeacherID == otherID {
+			cache.quoteSlice[i].TeacherID = ID
+		}
+	}
+
+	// there are no quotes of the teacher left, which would be deleted
+	return unsafeDeleteTeacherFromCache(otherID)
+}
+
 func unsafeDeleteQuoteFromCache(ID int32) error {
 	var enumIDRemove int32 = -1
 	var enumIDReplace int32 = -1
@@ -471,9 +526,25 @@ func unsafeDeleteQuoteFromCache(ID int32) error {
 		}
 	}
 
+	// the database deletes the votes by itself (ON DELETE CASCADE)
+	unsafeDeleteVotesOfQuoteFromCache(ID)
+
 	return nil
 }
 
+// unsafeDeleteVotesOfQuoteFromCache removes all votes for the quote with the given ID from the voteSlice
+func unsafeDeleteVotesOfQuoteFromCache(ID int32) {
+	for u, votes := range cache.voteSlice {
+		kept := votes[:0]
+		for _, vote := range votes {
+			if vote.QuoteID != ID {
+				kept = append(kept, vote)
+			}
+		}
+		cache.voteSlice[u] = kept
+	}
+}
+
 // Returns maximum amount of n quotes from cache starting from index from.
 // Returns nil if starting index is too big.
 func unsafeGetNQuotesFromFromCache(n, from int) []QuoteT {
@@ -498,6 +569,17 @@ func unsafeGetQuotesAmountFromCache() int {
 	return len(cache.quoteSlice)
 }
 
+func unsafeGetQuoteByIDFromCache(ID int32) (QuoteT, bool) {
+	for _, quote := range cache.quoteSlice {
+		if quote.QuoteID == ID {
+			return quote, true
+		}
+	}
+
+	// QuoteID = 0 indicates no matching quote has been found
+	return QuoteT{}, false
+}
+
 func unsafeGetTeachersFromCache() []TeacherT {
 	teacherSlice := make([]TeacherT, len(cache.teacherSlice))
 	copy(teacherSlice, cache.teacherSlice)
@@ -531,15 +613,51 @@ func unsafeGetQuotesByStringFromCache(text string) []QuoteT {
 	return quoteSlice
 }
 
-func unsafeGetUserFromCache(name string, password string) UserT {
+func unsafeGetUserByIDFromCache(ID int32) (UserT, bool) {
+	for _, user := range cache.userSlice {
+		if user.UserID == ID {
+			return user, true
+		}
+	}
+
+	// UserID = 0 indicates no matching user has been found
+	return UserT{}, false
+}
+
+// unsafeGetUserInfosFromCache returns all users, counting the votes in voteSlice
+// and the unverified quotes in the given slice
+func unsafeGetUserInfosFromCache(unverifiedQuotes []UnverifiedQuoteT) []UserInfoT {
+	submissions := make(map[int32]int)
+	for _, q := range unverifiedQuotes {
+		submissions[q.UserID]++
+	}
+
+	users := make([]UserInfoT, len(cache.userSlice))
+	for i, u := range cache.userSlice {
+		users[i] = UserInfoT{
+			UserID:      u.UserID,
+			Name:        u.Name,
+			Role:        u.Role,
+			Disabled:    u.Disabled,
+			Submissions: submissions[u.UserID],
+		}
+		if u.UserID >= 1 && int(u.UserID) <= len(cache.voteSlice) {
+			users[i].Votes = len(cache.voteSlice[u.UserID-1])
+		}
+	}
+	return users
+}
+
+// unsafeGetUserByNameFromCache finds a user by name, ignoring case
+func unsafeGetUserByNameFromCache(name string) (UserT, bool) {
 	for _, user := range cache.userSlice {
-		if strings.EqualFold(name, user.Name) && password == user.Password {
-			return user
+		if strings.EqualFold(name, user.Name) {
+			return user, true
 		}
 	}
 
 	// UserID = 0 indicates no matching user has been found
-	return UserT{}
+	return UserT{}, false
 }
 
 func unsafeAddUserDataToQuote(q *QuoteT, userid int32) error {
@@ -594,6 +712,14 @@ func calculateQuoteStats(quote *QuoteT) {
 	quote.Stats.Con = div / float32(num)
 }
 
+// unsafeRecalculateQuoteStats recalculates the stats of all quotes,
+// which is necessary whenever the number of users changes
+func unsafeRecalculateQuoteStats() {
+	for i := range cache.quoteSlice {
+		calculateQuoteStats(&cache.quoteSlice[i])
+	}
+}
+
 /* -------------------------------------------------------------------------- */
 /*                                  DEBUGGING                                 */
 /* -------------------------------------------------------------------------- */
@@ -113,10 +113,10 @@ func unsafeGetQuotesFromIndexedCache(n, from int, indexFn indexFunction) ([]Quot
 
 	// lengths of quoteIndexByTime, quoteIndexByPop
 	// and quoteIndexByCon ARE ALLWAYS EQUAL
-	if from >= len(quoteIndexByTime) {
+	if from < 0 || from >= len(quoteIndexByTime) {
 		return nil
 	}
-	if from+n >= len(quoteIndexByTime) {
+	if n >= len(quoteIndexByTime)-from {
 		n = len(quoteIndexByTime) - from
 	}
 	quoteSlice := make([]QuoteT, n)
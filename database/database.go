@@ -1,16 +1,10 @@
 package database
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
 	"log"
 	"sort"
-	"strings"
-	"os"
-
-	// loading postgresql driver
-	_ "github.com/lib/pq"
 )
 
 /* -------------------------------------------------------------------------- */
@@ -38,10 +32,8 @@ const VoteNone = 0
 // Unixtime   the time of submission; optional
 //
 // Stats	exists only locally, not saved in database!  \
-//    Pop	measure of the quote's popularity			 |
-//    Con	measure of the quote's controversy			 | Created from
-//    Data	array of the vote distribution				 | votes table
-// MyVote	exists only locally, not saved in database!  |
+//    	(see QuoteStatsT)							 | Created from
+// MyVote	exists only locally, not saved in database!  | votes table
 // 			(used by AddUserDataToQuotes)				 /
 //
 // Match	exists only locally, not saved in database!
@@ -53,12 +45,7 @@ type QuoteT struct {
 	Text      string
 	Unixtime  int64
 
-	Stats struct {
-		Num int32
-		Pop float32
-		Con float32
-		Data [VoteMax - VoteMin + 1]int32
-	}
+	Stats QuoteStatsT
 
 	// user / request specific
 	MyVote int8
@@ -66,6 +53,18 @@ type QuoteT struct {
 
 }
 
+// QuoteStatsT stores the stats of one quote, created from the votes table
+// Num   the number of votes
+// Pop   measure of the quote's popularity
+// Con   measure of the quote's controversy
+// Data  array of the vote distribution
+type QuoteStatsT struct {
+	Num  int32
+	Pop  float32
+	Con  float32
+	Data [VoteMax - VoteMin + 1]int32
+}
+
 // UnverifiedQuoteT stores one unverified quote
 // UserID       the unique ID of the submitting user
 // QuoteID      the unique ID of the unverified quote
@@ -99,13 +98,31 @@ type TeacherT struct {
 // UserT stores one user
 // UserID    the unique identifier of the user
 // Name      the user's name
-// Password  the user's password
-// Admin     flag if the user has admin priviliges
+// Password  the bcrypt hash of the user's password
+// Role      the user's role, which determines their permissions
+// Disabled  flag if the user is locked out, disabled users keep their votes
 type UserT struct {
 	UserID   int32
 	Name     string
 	Password string
-	Admin    bool
+	Role     RoleT
+	Disabled bool
+}
+
+// UserInfoT stores what admins get to see about one user
+// UserID       the unique identifier of the user
+// Name         the user's name
+// Role         the user's role
+// Disabled     flag if the user is locked out
+// Submissions  number of the user's unverified quotes
+// Votes        number of quotes the user voted for
+type UserInfoT struct {
+	UserID      int32
+	Name        string
+	Role        RoleT
+	Disabled    bool
+	Submissions int
+	Votes       int
 }
 
 // VoteT stores one vote
@@ -122,8 +139,8 @@ type VoteT struct {
 /*                          GLOBAL PACKAGE VARIABLES                          */
 /* -------------------------------------------------------------------------- */
 
-// Handle to the database, used as long time storage
-var database *sql.DB
+// Handle to the store, used as long time storage
+var store Store
 
 // globalMutex is to be used if a function of the database package must assure that every other
 // function is blocked
@@ -133,7 +150,8 @@ var globalMutex Mutex = Mutex{0, 0, false}
 /*                         EXPORTED GENERAL FUNCTIONS                         */
 /* -------------------------------------------------------------------------- */
 
-// Connect establishes the connection to the PostgresSQL database and therefore
+// Connect establishes the connection to the database selected by DB_DRIVER
+// (PostgreSQL by default, see openStore) and therefore
 // needs to be called before any other function of database.go.
 //
 // Notice: Connect doesn't initialize any tables or the cache, hence Initialize should be called
@@ -146,20 +164,12 @@ func Connect() error {
 
 	var err error
 
-	if database != nil {
-		database.Close()
-		database = nil
+	if store != nil {
+		store.Close()
+		store = nil
 	}
 
-	param := os.ExpandEnv(
-		`host=${DB_HOST}
-		 port=${DB_PORT}
-		 user=${DB_USER}
-		 password=${DB_PWD}
-		 dbname=${DB_NAME}
-		 sslmode=${DB_SSLMODE}`)
-
-	database, err = sql.Open("postgres", param)
+	store, err = openStore()
 	if err != nil {
 		return DBError{ "Connect: connecting to database failed", err }
 	}
@@ -167,7 +177,7 @@ func Connect() error {
 	return nil
 }
 
-// Initialize creates all the required tables in database, if they don't already exist
+// Initialize applies all pending schema migrations to the database
 // and initializes the cache from the database.
 //
 // Therefore it must be called before any other function of database.go despite Connect, which
@@ -175,7 +185,7 @@ func Connect() error {
 //
 // Possible returned error types: generic / DBError
 func Initialize() error {
-	if database == nil {
+	if store == nil {
 		return errors.New("Initialize: not connected to database")
 	}
 
@@ -183,78 +193,21 @@ func Initialize() error {
 	defer globalMutex.MajorUnlock()
 
 	// Verify connection to database
-	err := database.Ping()
+	err := store.Ping()
 	if err != nil {
-		database.Close()
+		store.Close()
 		return DBError{ "Initialize: pinging database failed", err }
 	}
 
-	// Create teachers table in database if it doesn't exist
-	// for more information see TeachersT declaration
-	_, err = database.Exec(
-		`CREATE TABLE IF NOT EXISTS teachers (
-		TeacherID serial PRIMARY KEY,
-		Name varchar,
-		Title varchar,
-		Note varchar)`)
-	if err != nil {
-		database.Close()
-		return DBError{ "Initialize: creating teachers table failed", err }
-	}
-
-	// Create quotes table in database if it doesn't exist
-	// for more information see QuoteT declaration
-	_, err = database.Exec(
-		`CREATE TABLE IF NOT EXISTS quotes (
-		QuoteID serial PRIMARY KEY,
-		TeacherID integer REFERENCES teachers (TeacherID) ON DELETE CASCADE,
-		Context varchar,
-		Text varchar,
-		Unixtime bigint)`)
+	err = store.Migrate()
 	if err != nil {
-		database.Close()
-		return DBError{ "Initialize: creating quotes table failed", err }
-	}
-
-	// Create users table in database if it doesn't exist
-	// for more information see UserT declaration
-	_, err = database.Exec(
-		`CREATE TABLE IF NOT EXISTS users (
-		UserID serial PRIMARY KEY,
-		Name varchar,
-		Password varchar,
-		Admin boolean)`)
-	if err != nil {
-		database.Close()
-		return DBError{ "Initialize: creating users table failed", err }
-	}
-
-	// Create unverifiedQuotes table in database if it doesn't exist
-	// for more information see UnverifiedQuoteT declaration
-	_, err = database.Exec(
-		`CREATE TABLE IF NOT EXISTS unverifiedQuotes (
-		UserID integer REFERENCES users (UserID) ON DELETE CASCADE,
-		QuoteID serial PRIMARY KEY,
-		TeacherID integer REFERENCES teachers (TeacherID) ON DELETE CASCADE,
-		TeacherName varchar,
-		Context varchar,
-		Text varchar,
-		Unixtime bigint)`)
-	if err != nil {
-		database.Close()
-		return DBError{ "Initialize: creating unverifiedQuotes table failed", err }
+		store.Close()
+		return DBError{ "Initialize: migrating database failed", err }
 	}
 
-	// Create votes table in database if it doesn't exist
-	_, err = database.Exec(
-		`CREATE TABLE IF NOT EXISTS votes (
-		Hash bigint PRIMARY KEY,
-		UserID integer REFERENCES users (UserID) ON DELETE CASCADE,
-		QuoteID integer REFERENCES quotes (QuoteID) ON DELETE CASCADE,
-		Rating smallint)`)
+	err = unsafeHashPlaintextPasswords()
 	if err != nil {
-		database.Close()
-		return DBError{ "Initialize: creating votes table failed", err }
+		return err
 	}
 
 	unsafeLoadCache()
@@ -262,18 +215,39 @@ func Initialize() error {
 	return nil
 }
 
+// Migrate applies all pending schema migrations to the database without loading the cache.
+// Initialize does this as well, so Migrate is only needed to migrate without starting up.
+//
+// Possible returned error types: generic / DBError
+func Migrate() error {
+	if store == nil {
+		return errors.New("Migrate: not connected to database")
+	}
+
+	globalMutex.MajorLock()
+	defer globalMutex.MajorUnlock()
+
+	// Verify connection to database
+	err := store.Ping()
+	if err != nil {
+		return DBError{ "Migrate: pinging database failed", err }
+	}
+
+	return store.Migrate()
+}
+
 // CloseAndClearCache closes database and cache.
 //
 // Possible returned error type: generic
 func CloseAndClearCache() error {
-	if database == nil {
+	if store == nil {
 		return errors.New("CloseAndClearCache: not connected to database")
 	}
 
 	globalMutex.MajorLock()
 	defer globalMutex.MajorUnlock()
 
-	database.Close()
+	store.Close()
 	unsafeClearCache()
 
 	return nil
@@ -281,22 +255,23 @@ func CloseAndClearCache() error {
 
 // ExecuteQuery runs a query on the database and returns the error
 // This function is to be used in a testing environment.
+// It only works if the store is backed by an SQL database.
 //
 // Possible returned error types: generic / DBError
 func ExecuteQuery(query string) error {
-	if database == nil {
+	if store == nil {
 		return errors.New("ExecuteQuery: not connected to database")
 	}
 
+	executer, ok := store.(interface{ ExecuteQuery(string) error })
+	if !ok {
+		return errors.New("ExecuteQuery: store doesn't support raw queries")
+	}
+
 	globalMutex.MajorLock()
 	defer globalMutex.MajorUnlock()
 
-	_, err := database.Exec(query)
-
-	if err != nil {
-		return DBError{ "ExecuteQuery: Exec failed", err }
-	}
-	return nil
+	return executer.ExecuteQuery(query)
 }
 
 /* -------------------------------------------------------------------------- */
@@ -310,7 +285,7 @@ func ExecuteQuery(query string) error {
 // THIS FUNCTION IS OUTDATED
 //
 func GetNQuotesFrom(n, from int) ([]QuoteT, error) {
-	if database == nil {
+	if store == nil {
 		return nil, errors.New("GetQuotes: not connected to database")
 	}
 
@@ -323,7 +298,7 @@ func GetNQuotesFrom(n, from int) ([]QuoteT, error) {
 
 // GetNSortedQuotesFrom returns n quotes starting with index from, as returned by indexFn
 func GetNSortedQuotesFrom(n, from int, indexFn indexFunction) ([]QuoteT, error) {
-	if database == nil {
+	if store == nil {
 		return nil, errors.New("GetNSortedQuotesFrom: not connected to database")
 	}
 
@@ -333,6 +308,54 @@ func GetNSortedQuotesFrom(n, from int, indexFn indexFunction) ([]QuoteT, error)
 	return unsafeGetQuotesFromIndexedCache(n, from, indexFn), nil
 }
 
+// GetNSortedQuotesOfTeacherFrom returns n quotes of the teacher with the given TeacherID
+// starting with index from, as returned by indexFn, and how many quotes of the teacher there are
+func GetNSortedQuotesOfTeacherFrom(teacherID int32, n, from int, indexFn indexFunction) ([]QuoteT, int, error) {
+	if store == nil {
+		return nil, 0, errors.New("GetNSortedQuotesOfTeacherFrom: not connected to database")
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	var quotes []QuoteT
+	for _, q := range unsafeGetQuotesFromIndexedCache(len(cache.quoteSlice), 0, indexFn) {
+		if q.TeacherID == teacherID {
+			quotes = append(quotes, q)
+		}
+	}
+
+	total := len(quotes)
+	if from < 0 || from >= total {
+		return nil, total, nil
+	}
+	if n < total-from {
+		quotes = quotes[:from+n]
+	}
+	return quotes[from:], total, nil
+}
+
+// GetQuoteByID returns the quote corresponding to the given ID.
+//
+// Possible returned error types: generic / InvalidQuoteIDError
+func GetQuoteByID(ID int32) (QuoteT, error) {
+	if store == nil {
+		return QuoteT{}, errors.New("GetQuoteByID: not connected to database")
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	quote, ok := unsafeGetQuoteByIDFromCache(ID)
+
+	if !ok {
+		// Quote not found
+		return QuoteT{}, InvalidQuoteIDError{"GetQuoteByID: no matching quote found"}
+	}
+
+	return quote, nil
+}
+
 // GetQuotesAmount returns how many quotes there are
 func GetQuotesAmount() int {
 	globalMutex.MinorLock()
@@ -347,6 +370,14 @@ func GetQuotesAmount() int {
 //
 // Possible returned error type: generic
 func GetMaxNQuotesByString(n int, text string) ([]QuoteT, error) {
+	return GetMaxNQuotesByStringAbove(n, 0, text)
+}
+
+// GetMaxNQuotesByStringAbove works like GetMaxNQuotesByString,
+// but also excludes the quotes whose weight (QuoteT.Match) is below minMatch.
+//
+// Possible returned error type: generic
+func GetMaxNQuotesByStringAbove(n int, minMatch float32, text string) ([]QuoteT, error) {
 	quotes, err := GetQuotesByString(text)
 	if err != nil {
 		return nil, err
@@ -355,7 +386,7 @@ func GetMaxNQuotesByString(n int, text string) ([]QuoteT, error) {
 	var relevantQuotes []QuoteT
 
 	for _,q := range quotes {
-		if q.Match > 0 {
+		if q.Match > 0 && q.Match >= minMatch {
 			relevantQuotes = append(relevantQuotes, q)
 		}
 	}
@@ -377,7 +408,7 @@ func GetMaxNQuotesByString(n int, text string) ([]QuoteT, error) {
 // The weight variable will indicate how well the given text matches the corresponding quote.
 // Possible returned error type: generic
 func GetQuotesByString(text string) ([]QuoteT, error) {
-	if database == nil {
+	if store == nil {
 		return nil, errors.New("GetQuotesByString: not connected to database")
 	}
 
@@ -392,7 +423,7 @@ func GetQuotesByString(text string) ([]QuoteT, error) {
 //
 // Possible returned error types: generic / DBError / InvalidTeacherIDError
 func CreateQuote(q QuoteT) error {
-	if database == nil {
+	if store == nil {
 		return errors.New("CreateQuote: not connected to database")
 	}
 
@@ -402,21 +433,16 @@ func CreateQuote(q QuoteT) error {
 	var err error
 
 	// Verify connection to database
-	err = database.Ping()
+	err = store.Ping()
 	if err != nil {
-		database.Close()
+		store.Close()
 		return DBError{ "CreateQuote: pinging database failed", err }
 	}
 
 	// add quote to database
-	err = database.QueryRow(
-		`INSERT INTO quotes (TeacherID, Context, Text, Unixtime) VALUES ($1, $2, $3, $4) RETURNING QuoteID`,
-		q.TeacherID, q.Context, q.Text, q.Unixtime).Scan(&q.QuoteID)
+	q.QuoteID, err = store.CreateQuote(q)
 	if err != nil {
-		if strings.Contains(err.Error(), "violates foreign key constraint") {
-			return InvalidTeacherIDError{ "CreateQuote: no teacher with given TeacherID" }
-		}
-		return DBError{ "CreateQuote: inserting quote into database failed", err }
+		return err
 	}
 
 	// add quote to cache
@@ -432,7 +458,7 @@ func CreateQuote(q QuoteT) error {
 //
 // Possible returned error types: generic / DBError / InvalidTeacherIDError / InvalidQuoteIDError
 func UpdateQuote(q QuoteT) error {
-	if database == nil {
+	if store == nil {
 		return errors.New("UpdateQuote: not connected to database")
 	}
 
@@ -446,25 +472,16 @@ func UpdateQuote(q QuoteT) error {
 	defer globalMutex.MajorUnlock()
 
 	// Verify connection to database
-	err = database.Ping()
+	err = store.Ping()
 	if err != nil {
-		database.Close()
+		store.Close()
 		return DBError{ "UpdateQuote: pinging database failed: ", err }
 	}
 
 	// try to find corresponding entry in database and overwrite it
-	var res sql.Result
-	res, err = database.Exec(
-		`UPDATE quotes SET TeacherID=$2, Context=$3, Text=$4 WHERE QuoteID=$1`,
-		q.QuoteID, q.TeacherID, q.Context, q.Text)
+	err = store.UpdateQuote(q)
 	if err != nil {
-		if strings.Contains(err.Error(), "violates foreign key constraint") {
-			return InvalidTeacherIDError{ "UpdateQuote: no teacher with given TeacherID" }
-		}
-		return DBError{ "UpdateQuote: updating quote in database failed", err }
-	}
-	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
-		return InvalidQuoteIDError{ "UpdateQuote: no matching database row found" }
+		return err
 	}
 
 	// try to find corresponding entry in cache and overwrite it
@@ -491,7 +508,7 @@ func UpdateQuote(q QuoteT) error {
 //
 // Possible returned error types: generic / DBError / InvalidQuoteIDError
 func DeleteQuote(ID int32) error {
-	if database == nil {
+	if store == nil {
 		return errors.New("DeleteQuote: not connected to database")
 	}
 
@@ -505,21 +522,16 @@ func DeleteQuote(ID int32) error {
 	defer globalMutex.MajorUnlock()
 
 	// Verify connection to database
-	err = database.Ping()
+	err = store.Ping()
 	if err != nil {
-		database.Close()
+		store.Close()
 		return DBError{ "DeleteQuote: pinging database failed", err }
 	}
 
 	// try to find corresponding entry in database and delete it
-	var res sql.Result
-	res, err = database.Exec(
-		`DELETE FROM quotes WHERE QuoteID=$1`, ID)
+	err = store.DeleteQuote(ID)
 	if err != nil {
-		return DBError{ "DeleteQuote: deleting quote from database failed", err }
-	}
-	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
-		return InvalidQuoteIDError{ "UpdateQuote: no matching database row found" }
+		return err
 	}
 
 	// try to find corresponding entry in cache and overwrite it
@@ -550,7 +562,7 @@ func DeleteQuote(ID int32) error {
 //
 // Possible returned error type: generic
 func GetTeachers() ([]TeacherT, error) {
-	if database == nil {
+	if store == nil {
 		return nil, errors.New("GetTeachers: not connected to database")
 	}
 
@@ -565,7 +577,7 @@ func GetTeachers() ([]TeacherT, error) {
 //
 // Possible returned error types: generic / InvalidTeacherIDError
 func GetTeacherByID(ID int32) (TeacherT, error) {
-	if database == nil {
+	if store == nil {
 		return TeacherT{}, errors.New("GetTeacherByID: not connected to database")
 	}
 
@@ -587,7 +599,7 @@ func GetTeacherByID(ID int32) (TeacherT, error) {
 //
 // Possible returned error types: generic / DBError
 func CreateTeacher(t TeacherT) error {
-	if database == nil {
+	if store == nil {
 		return errors.New("CreateTeacher: not connected to database")
 	}
 
@@ -597,18 +609,16 @@ func CreateTeacher(t TeacherT) error {
 	var err error
 
 	// Verify connection to database
-	err = database.Ping()
+	err = store.Ping()
 	if err != nil {
-		database.Close()
+		store.Close()
 		return DBError{ "CreateTeacher: pinging database failed", err }
 	}
 
 	// add teacher to database
-	err = database.QueryRow(
-		`INSERT INTO teachers (Name, Title, Note) VALUES ($1, $2, $3) RETURNING TeacherID`,
-		t.Name, t.Title, t.Note).Scan(&t.TeacherID)
+	t.TeacherID, err = store.CreateTeacher(t)
 	if err != nil {
-		return DBError{ "CreateTeacher: inserting teacher into database failed", err }
+		return err
 	}
 
 	// add teacher to cache
@@ -621,7 +631,7 @@ func CreateTeacher(t TeacherT) error {
 //
 // Possible returned error types: generic / DBError / InvalidTeacherIDError
 func UpdateTeacher(t TeacherT) error {
-	if database == nil {
+	if store == nil {
 		return errors.New("UpdateTeacher: not connected to database")
 	}
 
@@ -635,22 +645,16 @@ func UpdateTeacher(t TeacherT) error {
 	defer globalMutex.MajorUnlock()
 
 	// Verify connection to database
-	err = database.Ping()
+	err = store.Ping()
 	if err != nil {
-		database.Close()
+		store.Close()
 		return DBError{ "UpdateTeacher: pinging database failed: ", err }
 	}
 
 	// try to find corresponding entry in database and overwrite it
-	var res sql.Result
-	res, err = database.Exec(
-		`UPDATE teachers SET Name=$2, Title=$3, Note=$4 WHERE TeacherID=$1`,
-		t.TeacherID, t.Name, t.Title, t.Note)
+	err = store.UpdateTeacher(t)
 	if err != nil {
-		return DBError{ "UpdateTeacher: updating teacher in database failed", err }
-	}
-	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
-		return InvalidTeacherIDError{ "UpdateTeacher: no matching database row found" }
+		return err
 	}
 
 	// try to find corresponding entry in cache and overwrite it
@@ -671,11 +675,12 @@ func UpdateTeacher(t TeacherT) error {
 }
 
 // DeleteTeacher deletes the teacher corresponding to the given ID from the database and the teachers slice.
-// It will delete all corresponding quotes.
+// If cascade is set, it will delete all corresponding (unverified) quotes,
+// otherwise a teacher with (unverified) quotes is not deleted.
 //
-// Possible returned error types: generic / DBError / InvalidTeacherIDError
-func DeleteTeacher(ID int32) error {
-	if database == nil {
+// Possible returned error types: generic / DBError / InvalidTeacherIDError / TeacherInUseError
+func DeleteTeacher(ID int32, cascade bool) error {
+	if store == nil {
 		return errors.New("DeleteTeacher: not connected to database")
 	}
 
@@ -689,21 +694,16 @@ func DeleteTeacher(ID int32) error {
 	defer globalMutex.MajorUnlock()
 
 	// Verify connection to database
-	err = database.Ping()
+	err = store.Ping()
 	if err != nil {
-		database.Close()
+		store.Close()
 		return DBError{ "DeleteTeacher: pinging database failed: ", err }
 	}
 
 	// try to find corresponding entry in database and delete it
-	var res sql.Result
-	res, err = database.Exec(
-		`DELETE FROM teachers WHERE TeacherID=$1`, ID)
+	err = store.DeleteTeacher(ID, cascade)
 	if err != nil {
-		return DBError{ "DeleteTeacher: deleting teacher from database failed", err }
-	}
-	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
-		return InvalidTeacherIDError{ "DeleteTeacher: no matching database row found" }
+		return err
 	}
 
 	// try to find corresponding entry in cache and overwrite it
@@ -720,6 +720,62 @@ func DeleteTeacher(ID int32) error {
 		go Initialize()
 	}
 
+	// the cascade may have deleted quotes
+	unsafeForceCacheIndexGen()
+
+	return nil
+}
+
+// MergeTeachers assigns all (unverified) quotes of the teacher with otherID to the teacher with ID
+// and deletes the teacher with otherID, e.g. if a teacher was created twice.
+//
+// Possible returned error types: generic / DBError /
+// InvalidTeacherIDError (also if ID and otherID are the same)
+func MergeTeachers(ID int32, otherID int32) error {
+	if store == nil {
+		return errors.New("MergeTeachers: not connected to database")
+	}
+
+	var err error
+
+	if ID == 0 || otherID == 0 {
+		return InvalidTeacherIDError{ "MergeTeachers: TeacherID is zero" }
+	}
+
+	if ID == otherID {
+		return InvalidTeacherIDError{ "MergeTeachers: cannot merge a teacher with itself" }
+	}
+
+	globalMutex.MajorLock()
+	defer globalMutex.MajorUnlock()
+
+	// Verify connection to database
+	err = store.Ping()
+	if err != nil {
+		store.Close()
+		return DBError{ "MergeTeachers: pinging database failed", err }
+	}
+
+	err = store.MergeTeachers(ID, otherID)
+	if err != nil {
+		return err
+	}
+
+	err = unsafeMergeTeachersInCache(ID, otherID)
+	if err != nil {
+		// if this code is executed
+		// database was updated successfully but teacher cannot be found in cache
+		// thus cache and database are out of sync
+		// because the database is the only source of truth, MergeTeachers() should not fail,
+		// so the cache will be reloaded
+
+		log.Print("DATABASE: MergeTeachers: unsafeMergeTeachersInCache returned: " + err.Error())
+		log.Print("DATABASE: Cache is out of sync with database, trying to reload")
+		go Initialize()
+	}
+
+	unsafeForceCacheIndexGen()
+
 	return nil
 }
 
@@ -731,7 +787,7 @@ func DeleteTeacher(ID int32) error {
 //
 // Possible returned error types: generic / DBError
 func GetUnverifiedQuotes() ([]UnverifiedQuoteT, error) {
-	if database == nil {
+	if store == nil {
 		return nil, errors.New("GetUnverifiedQuotes: not connected to database")
 	}
 
@@ -739,56 +795,21 @@ func GetUnverifiedQuotes() ([]UnverifiedQuoteT, error) {
 	defer globalMutex.MinorUnlock()
 
 	// Verify connection to database
-	err := database.Ping()
+	err := store.Ping()
 	if err != nil {
-		database.Close()
+		store.Close()
 		return nil, DBError{ "GetUnverifiedQuotes: pinging database failed: ", err }
 	}
 
 	// get all unverifiedQuotes from database
-	rows, err := database.Query(`SELECT
-		UserID,
-		QuoteID,
-		TeacherID,
-		TeacherName,
-		Context,
-		Text,
-		Unixtime FROM unverifiedQuotes`)
-	if err != nil {
-		return nil, DBError{ "GetUnverifiedQuotes: loading unverifiedQuotes from database failed", err }
-	}
-
-	var quotes []UnverifiedQuoteT
-
-	// Iterate over all unverifiedQuotes from database
-	for rows.Next() {
-		// Get unverifiedQuotes data
-
-		var q UnverifiedQuoteT
-		var TeacherID sql.NullInt32
-
-		err := rows.Scan(&q.UserID, &q.QuoteID, &TeacherID, &q.TeacherName, &q.Context, &q.Text, &q.Unixtime)
-		if err != nil {
-			return nil, DBError{ "GetUnverifiedQuotes: parsing unverifiedQuotes failed", err }
-		}
-
-		// TeacherID can be nill, see CreateUnverifiedQuote and UpdateUnverifiedQuote
-		if TeacherID.Valid {
-			q.TeacherID = TeacherID.Int32
-		}
-
-		// Add unverifiedQuote to return slice
-		quotes = append(quotes, q)
-	}
-
-	return quotes, nil
+	return store.GetUnverifiedQuotes()
 }
 
 // GetUnverifiedQuoteByID returns a single unverified quote corresponding to the given ID.
 //
 // Possible returned error types: generic / DBError / InvalidQuoteIDError
 func GetUnverifiedQuoteByID(ID int32) (UnverifiedQuoteT, error) {
-	if database == nil {
+	if store == nil {
 		return UnverifiedQuoteT{}, errors.New("GetUnverifiedQuoteByID: not connected to database")
 	}
 
@@ -796,52 +817,21 @@ func GetUnverifiedQuoteByID(ID int32) (UnverifiedQuoteT, error) {
 	defer globalMutex.MinorUnlock()
 
 	// Verify connection to database
-	err := database.Ping()
+	err := store.Ping()
 	if err != nil {
-		database.Close()
+		store.Close()
 		return UnverifiedQuoteT{}, DBError{ "GetUnverifiedQuoteByID: pinging database failed: ", err }
 	}
 
-	// Query database
-	rows, err := database.Query(`SELECT
-		UserID,
-		TeacherID,
-		TeacherName,
-		Context,
-		Text,
-		Unixtime FROM unverifiedQuotes WHERE QuoteID=$1`, ID)
-	if err != nil {
-		return UnverifiedQuoteT{}, DBError{ "GetUnverifiedQuoteByID: loading unverifiedQuote from database failed", err }
-	}
-
-	if rows.Next() == false {
-		// QuoteID not found
-		return UnverifiedQuoteT{}, InvalidQuoteIDError{ "GetUnverifiedQuoteByID: no matching database row found" }
-	}
-
-	var q UnverifiedQuoteT
-	var TeacherID sql.NullInt32
-
-	err = rows.Scan(&q.UserID, &TeacherID, &q.TeacherName, &q.Context, &q.Text, &q.Unixtime)
-	if err != nil {
-		return UnverifiedQuoteT{}, DBError{ "GetUnverifiedQuoteByID: parsing unverifiedQuotes failed", err }
-	}
-
-	q.QuoteID = ID
-
-	// TeacherID can be nill, see CreateUnverifiedQuote and UpdateUnverifiedQuote
-	if TeacherID.Valid {
-		q.TeacherID = TeacherID.Int32
-	}
-
-	return q, nil
+	return store.GetUnverifiedQuoteByID(ID)
 }
 
 // CreateUnverifiedQuote stores an unverified quote.
 //
-// Possible returned error types: generic / DBError / InvalidTeacherIDError
+// Possible returned error types: generic / DBError / InvalidTeacherIDError /
+// InvalidUserIDError (if the submitter doesn't exist)
 func CreateUnverifiedQuote(q UnverifiedQuoteT) error {
-	if database == nil {
+	if store == nil {
 		return errors.New("CreateUnverifiedQuote: not connected to database")
 	}
 
@@ -851,33 +841,15 @@ func CreateUnverifiedQuote(q UnverifiedQuoteT) error {
 	var err error
 
 	// Verify connection to database
-	err = database.Ping()
+	err = store.Ping()
 	if err != nil {
-		database.Close()
+		store.Close()
 		return DBError{ "CreateUnverifiedQuote: pinging database failed", err }
 	}
 
 	// add quote to database - by ID or by name
-	if q.TeacherID != 0 {
-		_, err = database.Exec(
-			`INSERT INTO unverifiedQuotes (UserID, TeacherID, TeacherName, Context, Text, Unixtime) VALUES ($1, $2, $3, $4, $5, $6)`,
-			q.UserID, q.TeacherID, q.TeacherName, q.Context, q.Text, q.Unixtime)
-		if err != nil {
-			if strings.Contains(err.Error(), "violates foreign key constraint") {
-				return InvalidTeacherIDError{ "CreateUnverifiedQuote: no teacher with given TeacherID" }
-			}
-			return DBError{ "CreateUnverifiedQuote: inserting quote into database failed", err }
-		}
-	} else {
-		_, err = database.Exec(
-			`INSERT INTO unverifiedQuotes (UserID, TeacherID, TeacherName, Context, Text, Unixtime) VALUES ($1, $2, $3, $4, $5, $6)`,
-			q.UserID, nil, q.TeacherName, q.Context, q.Text, q.Unixtime)
-		if err != nil {
-			return DBError{ "CreateUnverifiedQuote: inserting quote into database failed", err }
-		}
-	}
-
-	return nil
+	_, err = store.CreateUnverifiedQuote(q)
+	return err
 }
 
 // UpdateUnverifiedQuote updates an unverified quote.
@@ -885,7 +857,7 @@ func CreateUnverifiedQuote(q UnverifiedQuoteT) error {
 //
 // Possible returned error types: generic / DBError / InvalidTeacherIDError / InvalidQuoteIDError
 func UpdateUnverifiedQuote(q UnverifiedQuoteT) error {
-	if database == nil {
+	if store == nil {
 		return errors.New("UpdateUnverifiedQuote: not connected to database")
 	}
 
@@ -893,45 +865,21 @@ func UpdateUnverifiedQuote(q UnverifiedQuoteT) error {
 	defer globalMutex.MinorUnlock()
 
 	// Verify connection to database
-	err := database.Ping()
+	err := store.Ping()
 	if err != nil {
-		database.Close()
+		store.Close()
 		return DBError{ "UpdateUnverifiedQuote: pinging database failed", err }
 	}
 
 	// try to find corresponding entry database and overwrite it
-	var res sql.Result
-	if q.TeacherID != 0 {
-		res, err = database.Exec(
-			`UPDATE unverifiedQuotes SET TeacherID=$2, TeacherName=$3, Context=$4, Text=$5 WHERE  QuoteID=$1`,
-			q.QuoteID, q.TeacherID, q.TeacherName, q.Context, q.Text)
-		if err != nil {
-			if strings.Contains(err.Error(), "violates foreign key constraint") {
-				return InvalidTeacherIDError{ "UpdateUnverifiedQuote: no teacher with given TeacherID" }
-			}
-			return DBError{ "UpdateUnverifiedQuote: updating unverifiedQuote in database failed", err }
-		}
-	} else {
-		res, err = database.Exec(
-			`UPDATE unverifiedQuotes SET TeacherID=$2, TeacherName=$3, Context=$4, Text=$5 WHERE  QuoteID=$1`,
-			q.QuoteID, nil, q.TeacherName, q.Context, q.Text)
-		if err != nil {
-			return DBError{ "UpdateUnverifiedQuote: updating unverifiedQuote in database failed", err }
-		}
-	}
-
-	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
-		return InvalidQuoteIDError{ "UpdateUnverifiedQuote: no matching database row found" }
-	}
-
-	return nil
+	return store.UpdateUnverifiedQuote(q)
 }
 
 // DeleteUnverifiedQuote deletes an unverified quote.
 //
 // Possible returned error types: generic / DBError / InvalidQuoteIDError
 func DeleteUnverifiedQuote(ID int32) error {
-	if database == nil {
+	if store == nil {
 		return errors.New("DeleteUnverifiedQuote: not connected to database")
 	}
 
@@ -939,26 +887,54 @@ func DeleteUnverifiedQuote(ID int32) error {
 	defer globalMutex.MinorUnlock()
 
 	// Verify connection to database
-	err := database.Ping()
+	err := store.Ping()
 	if err != nil {
-		database.Close()
+		store.Close()
 		return DBError{ "DeleteUnverifiedQuote: pinging database failed", err }
 	}
 
 	// try to find corresponding entry in database and delete it
-	var res sql.Result
-	res, err = database.Exec(
-		`DELETE FROM unverifiedQuotes WHERE  QuoteID=$1`, ID)
+	return store.DeleteUnverifiedQuote(ID)
+}
+
+// ConfirmUnverifiedQuote turns an unverified quote into a quote and returns the new QuoteID.
+// The unverified quote is deleted in the same transaction, so it can't end up in both tables.
+// The cache is only updated once the transaction has been committed.
+//
+// Possible returned error types: generic / DBError / InvalidQuoteIDError /
+// InvalidTeacherIDError (if the unverified quote has no valid TeacherID)
+func ConfirmUnverifiedQuote(ID int32) (int32, error) {
+	if store == nil {
+		return 0, errors.New("ConfirmUnverifiedQuote: not connected to database")
+	}
+
+	if ID == 0 {
+		return 0, InvalidQuoteIDError{ "ConfirmUnverifiedQuote: QuoteID is zero" }
+	}
+
+	globalMutex.MajorLock()
+	defer globalMutex.MajorUnlock()
+
+	// Verify connection to database
+	err := store.Ping()
 	if err != nil {
-		return DBError{ "DeleteUnverifiedQuote: deleting unverifiedQuote from database failed", err }
+		store.Close()
+		return 0, DBError{ "ConfirmUnverifiedQuote: pinging database failed", err }
 	}
-	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
-		return InvalidQuoteIDError{ "DeleteUnverifiedQuote: no matching database row found" }
+
+	// move quote in database
+	q, err := store.ConfirmUnverifiedQuote(ID)
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
-}
+	// add quote to cache
+	unsafeAddQuoteToCache(q)
 
+	unsafeForceCacheIndexGen()
+
+	return q.QuoteID, nil
+}
 
 /* -------------------------------------------------------------------------- */
 /*                          EXPORTED USERS FUNCTIONS                          */
@@ -970,9 +946,14 @@ func DeleteUnverifiedQuote(ID int32) error {
 // Possible returned error types: -
 func IsUser(name string, password string) int32 {
 	globalMutex.MinorLock()
-	defer globalMutex.MinorUnlock()
+	user, _ := unsafeGetUserByNameFromCache(name)
+	globalMutex.MinorUnlock()
 
-	return unsafeGetUserFromCache(name, password).UserID
+	// compared without holding the lock, because checking a hash is slow on purpose
+	if !checkPassword(user.Password, password) || user.Disabled {
+		return 0
+	}
+	return user.UserID
 }
 
 
@@ -982,49 +963,317 @@ func IsUser(name string, password string) int32 {
 //
 // Possible returned error types: -
 func IsAdmin(name string, password string) int32 {
+	globalMutex.MinorLock()
+	user, _ := unsafeGetUserByNameFromCache(name)
+	globalMutex.MinorUnlock()
+
+	if !checkPassword(user.Password, password) || !user.Role.Can(PermissionAdminister) || user.Disabled {
+		return 0
+	}
+	return user.UserID
+}
+
+// GetUserRole returns the role of the user with the given UserID,
+// e.g. after the user has been authenticated by a session.
+// If the user doesn't exist or is disabled, an empty role without any permissions is returned.
+//
+// Possible returned error types: -
+func GetUserRole(userid int32) RoleT {
 	globalMutex.MinorLock()
 	defer globalMutex.MinorUnlock()
 
-	user := unsafeGetUserFromCache(name, password)
-	if user.Admin {
-		return user.UserID
+	user, ok := unsafeGetUserByIDFromCache(userid)
+	if !ok || user.Disabled {
+		return ""
 	}
-	return 0
+	return user.Role
 }
 
-// GetUsernameByID fetches a user's username using their UserID from the database
+// GetUsers returns all users together with their number of submissions and votes
 //
-// Possible returned error types: generic / DBError / InvalidUserIDError
-func GetUsernameByID(userid int32) (string, error) {
-	if database == nil {
-		return "", errors.New("GetUsernameByID: not connected to database")
+// Possible returned error types: generic / DBError
+func GetUsers() ([]UserInfoT, error) {
+	if store == nil {
+		return nil, errors.New("GetUsers: not connected to database")
 	}
 
 	globalMutex.MinorLock()
 	defer globalMutex.MinorUnlock()
 
-	// get matching user from database
-	rows, err := database.Query(`SELECT Name FROM users WHERE UserID=$1`, userid)
+	// unverified quotes aren't cached
+	unverifiedQuotes, err := store.GetUnverifiedQuotes()
+	if err != nil {
+		return nil, err
+	}
+
+	users := unsafeGetUserInfosFromCache(unverifiedQuotes)
+	sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+	return users, nil
+}
+
+// GetUserByID returns the user with the given UserID, Password is left empty
+//
+// Possible returned error types: InvalidUserIDError
+func GetUserByID(ID int32) (UserT, error) {
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	user, ok := unsafeGetUserByIDFromCache(ID)
+	if !ok {
+		return UserT{}, InvalidUserIDError{"GetUserByID: no matching user found"}
+	}
+
+	user.Password = ""
+	return user, nil
+}
+
+// CreateUser creates a new user and returns its UserID.
+// u.Password is the plaintext password, only its hash is stored.
+//
+// Possible returned error types: generic / DBError / InvalidUserNameError / InvalidPasswordError
+func CreateUser(u UserT) (int32, error) {
+	if store == nil {
+		return 0, errors.New("CreateUser: not connected to database")
+	}
+
+	var err error
+
+	if u.Name == "" {
+		return 0, InvalidUserNameError{"CreateUser: Name is empty"}
+	}
+
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
+
+	if !u.Role.IsValid() {
+		return 0, fmt.Errorf("CreateUser: invalid Role %q", u.Role)
+	}
+
+	if len(u.Password) > maxPasswordLength {
+		return 0, InvalidPasswordError{"CreateUser: password is longer than 72 bytes"}
+	}
+
+	u.Password, err = hashPassword(u.Password)
 	if err != nil {
-		return "", DBError{ "GetUsernameByID: loading users from database failed", err }
+		return 0, errors.New("CreateUser: hashing password failed: " + err.Error())
 	}
 
-	var username string
+	globalMutex.MajorLock()
+	defer globalMutex.MajorUnlock()
 
-	// Iterate over the one matching user
-	if rows.Next() {
-		// Get user data
+	// names are compared ignoring case when logging in, so they have to be unique that way
+	if _, taken := unsafeGetUserByNameFromCache(u.Name); taken {
+		return 0, InvalidUserNameError{"CreateUser: Name is already taken"}
+	}
 
-		err := rows.Scan(&username)
+	// Verify connection to database
+	err = store.Ping()
+	if err != nil {
+		store.Close()
+		return 0, DBError{ "CreateUser: pinging database failed", err }
+	}
+
+	// add user to database
+	u.UserID, err = store.CreateUser(u)
+	if err != nil {
+		return 0, err
+	}
+
+	// add user to cache
+	unsafeAddUserToCache(u)
+
+	// the number of users is part of every quote's popularity
+	unsafeRecalculateQuoteStats()
+	requestCacheIndexGen()
+
+	return u.UserID, nil
+}
+
+// UpdateUser overwrites Name, Role and Disabled of the user with u.UserID and,
+// unless password is empty, the password like SetUserPassword. u.Password is ignored.
+// Everything is changed in one transaction. Disabling a user or setting the password
+// ends all of their sessions, setting the password deletes all of their API tokens as well.
+//
+// Possible returned error types: generic / DBError / InvalidUserIDError / InvalidUserNameError /
+// InvalidPasswordError
+func UpdateUser(u UserT, password string) error {
+	if store == nil {
+		return errors.New("UpdateUser: not connected to database")
+	}
+
+	if u.UserID == 0 {
+		return InvalidUserIDError{"UpdateUser: UserID is zero"}
+	}
+
+	if u.Name == "" {
+		return InvalidUserNameError{"UpdateUser: Name is empty"}
+	}
+
+	if !u.Role.IsValid() {
+		return fmt.Errorf("UpdateUser: invalid Role %q", u.Role)
+	}
+
+	if len(password) > maxPasswordLength {
+		return InvalidPasswordError{"UpdateUser: password is longer than 72 bytes"}
+	}
+
+	// an empty u.Password keeps the stored one
+	u.Password = ""
+	if password != "" {
+		hash, err := hashPassword(password)
 		if err != nil {
-			return "", DBError{ "GetUsernameByID: parsing user data failed", err }
+			return errors.New("UpdateUser: hashing password failed: " + err.Error())
 		}
-	} else {
-		// User not found
-		return "", InvalidUserIDError{ "GetUsernameByID: no matching user found" }
+		u.Password = hash
+	}
+
+	globalMutex.MajorLock()
+	defer globalMutex.MajorUnlock()
+
+	old, ok := unsafeGetUserByIDFromCache(u.UserID)
+	if !ok {
+		return InvalidUserIDError{"UpdateUser: no matching user found"}
+	}
+
+	if other, taken := unsafeGetUserByNameFromCache(u.Name); taken && other.UserID != u.UserID {
+		return InvalidUserNameError{"UpdateUser: Name is already taken"}
+	}
+
+	// Verify connection to database
+	err := store.Ping()
+	if err != nil {
+		store.Close()
+		return DBError{ "UpdateUser: pinging database failed", err }
+	}
+
+	err = store.UpdateUser(u, u.Disabled || u.Password != "")
+	if err != nil {
+		return err
+	}
+
+	if u.Password == "" {
+		u.Password = old.Password
+	}
+	err = unsafeOverwriteUserInCache(u)
+	if err != nil {
+		log.Print("DATABASE: UpdateUser: unsafeOverwriteUserInCache returned: " + err.Error())
+		log.Print("DATABASE: Cache is out of sync with database, trying to reload")
+		go Initialize()
+	}
+
+	return nil
+}
+
+// SetUserPassword replaces the password of the user with the given UserID,
+// password is the plaintext password, only its hash is stored.
+// All sessions of the user are ended, so they have to log in with the new password,
+// and all of their API tokens are deleted.
+//
+// Possible returned error types: generic / DBError / InvalidUserIDError / InvalidPasswordError
+func SetUserPassword(ID int32, password string) error {
+	if store == nil {
+		return errors.New("SetUserPassword: not connected to database")
+	}
+
+	if ID == 0 {
+		return InvalidUserIDError{"SetUserPassword: UserID is zero"}
+	}
+
+	if len(password) > maxPasswordLength {
+		return InvalidPasswordError{"SetUserPassword: password is longer than 72 bytes"}
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return errors.New("SetUserPassword: hashing password failed: " + err.Error())
+	}
+
+	globalMutex.MajorLock()
+	defer globalMutex.MajorUnlock()
+
+	user, ok := unsafeGetUserByIDFromCache(ID)
+	if !ok {
+		return InvalidUserIDError{"SetUserPassword: no matching user found"}
+	}
+
+	// Verify connection to database
+	err = store.Ping()
+	if err != nil {
+		store.Close()
+		return DBError{ "SetUserPassword: pinging database failed", err }
+	}
+
+	// the password, the sessions and the API tokens are changed in one transaction
+	user.Password = hash
+	err = store.UpdateUser(user, true)
+	if err != nil {
+		return err
 	}
 
-	return username, nil
+	err = unsafeOverwriteUserInCache(user)
+	if err != nil {
+		log.Print("DATABASE: SetUserPassword: unsafeOverwriteUserInCache returned: " + err.Error())
+		log.Print("DATABASE: Cache is out of sync with database, trying to reload")
+		go Initialize()
+	}
+
+	return nil
+}
+
+// DeleteUser deletes the user with the given UserID together with
+// their unverified quotes, votes, sessions and API tokens.
+//
+// Possible returned error types: generic / DBError / InvalidUserIDError
+func DeleteUser(ID int32) error {
+	if store == nil {
+		return errors.New("DeleteUser: not connected to database")
+	}
+
+	if ID == 0 {
+		return InvalidUserIDError{"DeleteUser: UserID is zero"}
+	}
+
+	globalMutex.MajorLock()
+	defer globalMutex.MajorUnlock()
+
+	// Verify connection to database
+	err := store.Ping()
+	if err != nil {
+		store.Close()
+		return DBError{ "DeleteUser: pinging database failed", err }
+	}
+
+	err = store.DeleteUser(ID)
+	if err != nil {
+		return err
+	}
+
+	err = unsafeDeleteUserFromCache(ID)
+	if err != nil {
+		log.Print("DATABASE: DeleteUser: unsafeDeleteUserFromCache returned: " + err.Error())
+		log.Print("DATABASE: Cache is out of sync with database, trying to reload")
+		go Initialize()
+	}
+
+	unsafeForceCacheIndexGen()
+
+	return nil
+}
+
+// GetUsernameByID fetches a user's username using their UserID from the database
+//
+// Possible returned error types: generic / DBError / InvalidUserIDError
+func GetUsernameByID(userid int32) (string, error) {
+	if store == nil {
+		return "", errors.New("GetUsernameByID: not connected to database")
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	// get matching user from database
+	return store.GetUsernameByID(userid)
 }
 
 // AddUserDataToQuotes adds all the user specific information to the quotes
@@ -1051,6 +1300,10 @@ func AddUserDataToQuotes(quotes []QuoteT, userid int32) error {
 // AddVote adds a vote with Rating (1-5) from one user for one quote to the database
 // Possible returned error types: generic / DBError / InvalidQuoteIDError
 func AddVote(vote VoteT) (QuoteT, error) {
+	if store == nil {
+		return QuoteT{}, errors.New("AddVote: not connected to database")
+	}
+
 	if vote.UserID < 1 {
 		// u must be greater than zero to be a valid UserID
 		return QuoteT{}, errors.New("AddVote: invalid UserID, must be greater than zero")
@@ -1060,32 +1313,63 @@ func AddVote(vote VoteT) (QuoteT, error) {
 		return QuoteT{}, fmt.Errorf("AddVote: invalid Rating, must be in range %d-%d", VoteMin, VoteMax)
 	}
 
+	globalMutex.MajorLock()
+	defer globalMutex.MajorUnlock()
+
 	// Verify connection to database
-	err := database.Ping()
+	err := store.Ping()
 	if err != nil {
-		database.Close()
+		store.Close()
 		return QuoteT{}, DBError{ "AddVote: pinging database failed", err }
 	}
 
 	// add vote to database, update if necessary
-	_, err = database.Exec(
-		`INSERT INTO votes (Hash, UserID, QuoteID, Rating) VALUES ($1, $2, $3, $4)
-		 ON CONFLICT (Hash) DO UPDATE SET
-			UserID=EXCLUDED.UserID, QuoteID=EXCLUDED.QuoteID, Rating=EXCLUDED.Rating;`,
-		voteHash(vote), vote.UserID, vote.QuoteID, vote.Val)
-
+	err = store.PutVote(vote)
 	if err != nil {
-		if strings.Contains(err.Error(), "violates foreign key constraint \"votes_quoteid_fkey\"") {
-			return QuoteT{}, InvalidQuoteIDError{ "AddVoteIDError: QuoteID unknown" }
-		}
-		return QuoteT{}, DBError{ "AddVote: inserting vote into database failed", err }
+		return QuoteT{}, err
+	}
+
+	// add vote to cache
+	quote, err := unsafeAddVoteToCache(vote)
+
+	requestCacheIndexGen()
+	return quote, err
+}
+
+// DeleteVote retracts the vote of one user for one quote and returns the quote with updated Stats.
+// It is no error if the user hadn't voted for the quote.
+// Possible returned error types: generic / DBError / InvalidQuoteIDError
+func DeleteVote(userID int32, quoteID int32) (QuoteT, error) {
+	if store == nil {
+		return QuoteT{}, errors.New("DeleteVote: not connected to database")
+	}
+
+	if userID < 1 {
+		// u must be greater than zero to be a valid UserID
+		return QuoteT{}, errors.New("DeleteVote: invalid UserID, must be greater than zero")
 	}
 
 	globalMutex.MajorLock()
 	defer globalMutex.MajorUnlock()
 
-	// add vote to cache
-	quote, err := unsafeAddVoteToCache(vote)
+	if _, ok := unsafeGetQuoteByIDFromCache(quoteID); !ok {
+		return QuoteT{}, InvalidQuoteIDError{"DeleteVote: QuoteID unknown"}
+	}
+
+	// Verify connection to database
+	err := store.Ping()
+	if err != nil {
+		store.Close()
+		return QuoteT{}, DBError{ "DeleteVote: pinging database failed", err }
+	}
+
+	err = store.DeleteVote(userID, quoteID)
+	if err != nil {
+		return QuoteT{}, err
+	}
+
+	// remove vote from cache
+	quote, err := unsafeDeleteVoteFromCache(userID, quoteID)
 
 	requestCacheIndexGen()
 	return quote, err
@@ -1095,6 +1379,41 @@ func AddVote(vote VoteT) (QuoteT, error) {
 /*                         UNEXPORTED HELPER FUNCTIONS                        */
 /* -------------------------------------------------------------------------- */
 
+// unsafeHashPlaintextPasswords replaces the plaintext passwords stored before
+// passwords were hashed by their hashes. Rows that are already hashed are left alone,
+// so this is a no-op after it has run once.
+// unsafe functions aren't concurrency safe
+func unsafeHashPlaintextPasswords() error {
+	users, err := store.GetUsers()
+	if err != nil {
+		return err
+	}
+
+	hashed := 0
+	for _, u := range users {
+		if isPasswordHash(u.Password) {
+			continue
+		}
+
+		hash, err := hashPassword(u.Password)
+		if err != nil {
+			return errors.New("unsafeHashPlaintextPasswords: hashing password failed: " + err.Error())
+		}
+
+		err = store.UpdateUserPassword(u.UserID, hash)
+		if err != nil {
+			return err
+		}
+		hashed++
+	}
+
+	if hashed > 0 {
+		log.Printf("Hashed %d plaintext password(s)", hashed)
+	}
+
+	return nil
+}
+
 func voteHash(vote VoteT) int64 {
 	return int64(vote.UserID)<<32 | int64(vote.QuoteID)
 }
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// InviteT stores one invite code, which lets people register themselves
+// InviteID  the unique identifier of the invite
+// MaxUses   how many users can register with the code
+// Uses      how many users have registered with the code so far
+// Expires   the unixtime after which the code can no longer be used
+// Role      the role of users registering with the code
+//
+// Like session tokens, the code itself is only shown once when creating the invite,
+// the database stores its hash (see hashToken)
+type InviteT struct {
+	InviteID int32
+	MaxUses  int32
+	Uses     int32
+	Expires  int64
+	Role     RoleT
+}
+
+/* -------------------------------------------------------------------------- */
+/*                         EXPORTED INVITES FUNCTIONS                         */
+/* -------------------------------------------------------------------------- */
+
+// GetInvites returns all invites, sorted by InviteID
+//
+// Possible returned error types: generic / DBError
+func GetInvites() ([]InviteT, error) {
+	if store == nil {
+		return nil, errors.New("GetInvites: not connected to database")
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	invites, err := store.GetInvites()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(invites, func(i, j int) bool { return invites[i].InviteID < invites[j].InviteID })
+	return invites, nil
+}
+
+// CreateInvite creates a new invite from inv (InviteID and Uses are ignored)
+// and returns its InviteID and code, which needs to be presented to RegisterUser.
+//
+// Possible returned error types: generic / DBError
+func CreateInvite(inv InviteT) (int32, string, error) {
+	if store == nil {
+		return 0, "", errors.New("CreateInvite: not connected to database")
+	}
+
+	if inv.MaxUses < 1 {
+		return 0, "", errors.New("CreateInvite: MaxUses must be greater than zero")
+	}
+
+	if inv.Role == "" {
+		inv.Role = RoleUser
+	}
+
+	if !inv.Role.IsValid() {
+		return 0, "", fmt.Errorf("CreateInvite: invalid Role %q", inv.Role)
+	}
+
+	code, err := generateToken()
+	if err != nil {
+		return 0, "", errors.New("CreateInvite: generating code failed: " + err.Error())
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	// Verify connection to database
+	err = store.Ping()
+	if err != nil {
+		return 0, "", DBError{"CreateInvite: pinging database failed", err}
+	}
+
+	id, err := store.CreateInvite(hashToken(code), inv)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return id, code, nil
+}
+
+// DeleteInvite deletes the invite with the given InviteID, users who registered with it are kept
+//
+// Possible returned error types: generic / DBError / InvalidInviteIDError
+func DeleteInvite(ID int32) error {
+	if store == nil {
+		return errors.New("DeleteInvite: not connected to database")
+	}
+
+	if ID == 0 {
+		return InvalidInviteIDError{"DeleteInvite: InviteID is zero"}
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	return store.DeleteInvite(ID)
+}
+
+// RegisterUser creates a new user using an invite code and returns its UserID.
+// password is the plaintext password, only its hash is stored.
+// The user gets the role of the invite.
+//
+// Possible returned error types: generic / DBError / InvalidUserNameError / InvalidPasswordError /
+// InvalidTokenError (if the code is unknown, expired or used up)
+func RegisterUser(code string, name string, password string) (int32, error) {
+	if store == nil {
+		return 0, errors.New("RegisterUser: not connected to database")
+	}
+
+	if name == "" {
+		return 0, InvalidUserNameError{"RegisterUser: Name is empty"}
+	}
+
+	if len(password) > maxPasswordLength {
+		return 0, InvalidPasswordError{"RegisterUser: password is longer than 72 bytes"}
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return 0, errors.New("RegisterUser: hashing password failed: " + err.Error())
+	}
+
+	globalMutex.MajorLock()
+	defer globalMutex.MajorUnlock()
+
+	// names are compared ignoring case when logging in, so they have to be unique that way
+	if _, taken := unsafeGetUserByNameFromCache(name); taken {
+		return 0, InvalidUserNameError{"RegisterUser: Name is already taken"}
+	}
+
+	// Verify connection to database
+	err = store.Ping()
+	if err != nil {
+		store.Close()
+		return 0, DBError{"RegisterUser: pinging database failed", err}
+	}
+
+	u, err := store.CreateUserWithInvite(hashToken(code), UserT{Name: name, Password: hash}, time.Now().Unix())
+	if err != nil {
+		return 0, err
+	}
+
+	// add user to cache, so they can log in right away
+	unsafeAddUserToCache(u)
+
+	// the number of users is part of every quote's popularity
+	unsafeRecalculateQuoteStats()
+	requestCacheIndexGen()
+
+	return u.UserID, nil
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// migrationT stores one up-migration
+// Version  the number the file name starts with, migrations are applied in ascending order
+// Name     the rest of the file name, without extension
+// Query    the SQL statements of the migration
+type migrationT struct {
+	Version int
+	Name    string
+	Query   string
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          GLOBAL PACKAGE VARIABLES                          */
+/* -------------------------------------------------------------------------- */
+
+// migrationFiles contains one directory of migrations per sqlDialect.
+// Migrations are named <version>_<name>.sql, e.g. 0001_initial.sql.
+// Never edit a migration that has been released, add a new one instead.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+/* -------------------------------------------------------------------------- */
+/*                             MIGRATION FUNCTIONS                            */
+/* -------------------------------------------------------------------------- */
+
+// loadMigrations returns the migrations in migrations/<dir>, sorted by version
+func loadMigrations(dir string) ([]migrationT, error) {
+	dir = path.Join("migrations", dir)
+
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("loadMigrations: reading %s failed: %v", dir, err)
+	}
+
+	var migrations []migrationT
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimSuffix(entry.Name(), ".sql"), "_", 2)
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || version <= 0 || len(parts) != 2 {
+			return nil, fmt.Errorf("loadMigrations: invalid migration file name %s", entry.Name())
+		}
+
+		query, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("loadMigrations: reading %s failed: %v", entry.Name(), err)
+		}
+
+		migrations = append(migrations, migrationT{version, parts[1], string(query)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("loadMigrations: version %d exists twice", migrations[i].Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Migrate applies all pending migrations of the dialect.
+//
+// Everything happens in one transaction, which first takes the dialect's migration lock.
+// This way two instances starting at the same time don't both apply a migration and
+// a failing migration leaves the database untouched.
+func (s *sqlStore) Migrate() error {
+	migrations, err := loadMigrations(s.dialect.migrations)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return DBError{"Migrate: beginning transaction failed", err}
+	}
+	defer tx.Rollback()
+
+	if s.dialect.lockMigrations != "" {
+		_, err = tx.Exec(s.dialect.lockMigrations)
+		if err != nil {
+			return DBError{"Migrate: acquiring migration lock failed", err}
+		}
+	}
+
+	_, err = tx.Exec(
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+		Version integer PRIMARY KEY,
+		Name varchar,
+		Unixtime bigint)`)
+	if err != nil {
+		return DBError{"Migrate: creating schema_migrations table failed", err}
+	}
+
+	rows, err := tx.Query(`SELECT Version FROM schema_migrations`)
+	if err != nil {
+		return DBError{"Migrate: loading applied migrations failed", err}
+	}
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		err = rows.Scan(&version)
+		if err != nil {
+			rows.Close()
+			return DBError{"Migrate: parsing applied migrations failed", err}
+		}
+		applied[version] = true
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return DBError{"Migrate: reading applied migrations failed", err}
+	}
+
+	pending := 0
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+
+		log.Printf("Applying migration %04d_%s", m.Version, m.Name)
+
+		_, err = tx.Exec(m.Query)
+		if err != nil {
+			return DBError{fmt.Sprintf("Migrate: applying migration %04d_%s failed", m.Version, m.Name), err}
+		}
+
+		_, err = tx.Exec(`INSERT INTO schema_migrations (Version, Name, Unixtime) VALUES ($1, $2, $3)`,
+			m.Version, m.Name, time.Now().Unix())
+		if err != nil {
+			return DBError{"Migrate: recording migration failed", err}
+		}
+
+		pending++
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return DBError{"Migrate: committing migrations failed", err}
+	}
+
+	if pending > 0 {
+		log.Printf("Applied %d migration(s)", pending)
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+-- for more information see InviteT declaration
+CREATE TABLE invites (
+	InviteID serial PRIMARY KEY,
+	CodeHash varchar UNIQUE,
+	MaxUses integer,
+	Uses integer,
+	Expires bigint,
+	Admin boolean);
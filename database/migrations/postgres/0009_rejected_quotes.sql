@@ -0,0 +1,17 @@
+-- confirmed quotes remember who submitted them, see SubmissionT
+-- quotes confirmed before this migration have no submitter
+ALTER TABLE quotes ADD COLUMN UserID integer REFERENCES users (UserID) ON DELETE SET NULL;
+
+-- for more information see RejectedQuoteT declaration
+-- QuoteID is the QuoteID the quote had as unverified quote
+CREATE TABLE rejectedQuotes (
+	UserID integer REFERENCES users (UserID) ON DELETE CASCADE,
+	QuoteID integer PRIMARY KEY,
+	TeacherID integer REFERENCES teachers (TeacherID) ON DELETE SET NULL,
+	TeacherName varchar,
+	Context varchar,
+	Text varchar,
+	Unixtime bigint,
+	Reason varchar,
+	Note varchar,
+	Rejected bigint);
@@ -0,0 +1,42 @@
+-- The tables as created by Initialize before there were migrations.
+-- IF NOT EXISTS is kept so that existing deployments adopt this migration.
+-- serial columns are INTEGER PRIMARY KEY AUTOINCREMENT, so that IDs are never reused.
+
+-- for more information see TeachersT declaration
+CREATE TABLE IF NOT EXISTS teachers (
+	TeacherID INTEGER PRIMARY KEY AUTOINCREMENT,
+	Name varchar,
+	Title varchar,
+	Note varchar);
+
+-- for more information see QuoteT declaration
+CREATE TABLE IF NOT EXISTS quotes (
+	QuoteID INTEGER PRIMARY KEY AUTOINCREMENT,
+	TeacherID integer REFERENCES teachers (TeacherID) ON DELETE CASCADE,
+	Context varchar,
+	Text varchar,
+	Unixtime bigint);
+
+-- for more information see UserT declaration
+CREATE TABLE IF NOT EXISTS users (
+	UserID INTEGER PRIMARY KEY AUTOINCREMENT,
+	Name varchar,
+	Password varchar,
+	Admin boolean);
+
+-- for more information see UnverifiedQuoteT declaration
+CREATE TABLE IF NOT EXISTS unverifiedQuotes (
+	UserID integer REFERENCES users (UserID) ON DELETE CASCADE,
+	QuoteID INTEGER PRIMARY KEY AUTOINCREMENT,
+	TeacherID integer REFERENCES teachers (TeacherID) ON DELETE CASCADE,
+	TeacherName varchar,
+	Context varchar,
+	Text varchar,
+	Unixtime bigint);
+
+-- for more information see VoteT declaration
+CREATE TABLE IF NOT EXISTS votes (
+	Hash bigint PRIMARY KEY,
+	UserID integer REFERENCES users (UserID) ON DELETE CASCADE,
+	QuoteID integer REFERENCES quotes (QuoteID) ON DELETE CASCADE,
+	Rating smallint);
@@ -0,0 +1,5 @@
+-- for more information see SessionT declaration
+CREATE TABLE sessions (
+	TokenHash varchar PRIMARY KEY,
+	UserID integer REFERENCES users (UserID) ON DELETE CASCADE,
+	Expires bigint);
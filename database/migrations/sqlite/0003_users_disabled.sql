@@ -0,0 +1,2 @@
+-- disabled users can neither log in nor use their sessions, see UserT
+ALTER TABLE users ADD COLUMN Disabled boolean NOT NULL DEFAULT false;
@@ -0,0 +1,8 @@
+-- the Admin flags are replaced by roles, see RoleT
+ALTER TABLE users ADD COLUMN Role varchar NOT NULL DEFAULT 'user';
+UPDATE users SET Role='admin' WHERE Admin;
+ALTER TABLE users DROP COLUMN Admin;
+
+ALTER TABLE invites ADD COLUMN Role varchar NOT NULL DEFAULT 'user';
+UPDATE invites SET Role='admin' WHERE Admin;
+ALTER TABLE invites DROP COLUMN Admin;
@@ -0,0 +1,9 @@
+-- for more information see TokenT declaration
+CREATE TABLE tokens (
+	TokenID INTEGER PRIMARY KEY AUTOINCREMENT,
+	TokenHash varchar UNIQUE,
+	UserID integer REFERENCES users (UserID) ON DELETE CASCADE,
+	Name varchar,
+	Scope varchar,
+	Created bigint,
+	Expires bigint);
@@ -0,0 +1,5 @@
+-- for more information see PasswordResetT declaration
+CREATE TABLE password_resets (
+	TokenHash varchar PRIMARY KEY,
+	UserID integer REFERENCES users (UserID) ON DELETE CASCADE,
+	Expires bigint);
@@ -0,0 +1,6 @@
+-- for more information see RateLimitT declaration
+-- limits without a row here use their default, see defaultRateLimits
+CREATE TABLE rate_limits (
+	Name varchar PRIMARY KEY,
+	Burst integer,
+	PerHour integer);
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// ModerationT is an action a moderator applies to several unverified quotes at once
+// Action     what to do with the unverified quotes
+// TeacherID  the teacher to assign, only used by ActionAssignTeacher
+// Reason     why the unverified quotes are rejected, only used by ActionReject
+// Note       an explanation of the rejection, see RejectedQuoteT
+type ModerationT struct {
+	Action    ActionT
+	TeacherID int32
+	Reason    ReasonT
+	Note      string
+}
+
+// ActionT is a moderation action
+type ActionT string
+
+// ModerationResultT is the outcome of a moderation action for one unverified quote
+// QuoteID  the QuoteID of the unverified quote
+// Quote    the new quote if the unverified quote was confirmed and the transaction committed
+// Err      why the action failed for this unverified quote, nil if it succeeded
+type ModerationResultT struct {
+	QuoteID int32
+	Quote   QuoteT
+	Err     error
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                  CONSTANTS                                 */
+/* -------------------------------------------------------------------------- */
+
+// The moderation actions
+const (
+	// ActionConfirm turns the unverified quotes into quotes, like ConfirmUnverifiedQuote
+	ActionConfirm ActionT = "confirm"
+	// ActionReject rejects the unverified quotes, like RejectUnverifiedQuote
+	ActionReject ActionT = "reject"
+	// ActionAssignTeacher sets the TeacherID of the unverified quotes and clears their TeacherName
+	ActionAssignTeacher ActionT = "assignteacher"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                         EXPORTED MODERATION FUNCTIONS                      */
+/* -------------------------------------------------------------------------- */
+
+// Actions returns all valid moderation actions
+func Actions() []ActionT {
+	return []ActionT{ActionConfirm, ActionReject, ActionAssignTeacher}
+}
+
+// IsValid checks if action is one of the defined actions
+func (action ActionT) IsValid() bool {
+	for _, a := range Actions() {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// ModerateUnverifiedQuotes applies m to the unverified quotes with the given QuoteIDs in one transaction
+// and returns one result per QuoteID, in the same order. If the action fails for any of them,
+// nothing is changed and the results tell which ones failed.
+// The cache is only updated once the transaction has been committed.
+//
+// Possible error types of a result: InvalidQuoteIDError /
+// InvalidTeacherIDError (if the teacher to assign doesn't exist or, when confirming,
+// the unverified quote has no valid TeacherID)
+//
+// Possible returned error types: generic / DBError
+func ModerateUnverifiedQuotes(IDs []int32, m ModerationT) ([]ModerationResultT, error) {
+	if store == nil {
+		return nil, errors.New("ModerateUnverifiedQuotes: not connected to database")
+	}
+
+	if !m.Action.IsValid() {
+		return nil, fmt.Errorf("ModerateUnverifiedQuotes: invalid Action %q", m.Action)
+	}
+
+	if m.Action == ActionReject {
+		if !m.Reason.IsValid() {
+			return nil, fmt.Errorf("ModerateUnverifiedQuotes: invalid Reason %q", m.Reason)
+		}
+		if m.Reason == ReasonOther && m.Note == "" {
+			return nil, errors.New("ModerateUnverifiedQuotes: Note is required for Reason other")
+		}
+	}
+
+	globalMutex.MajorLock()
+	defer globalMutex.MajorUnlock()
+
+	// Verify connection to database
+	err := store.Ping()
+	if err != nil {
+		store.Close()
+		return nil, DBError{"ModerateUnverifiedQuotes: pinging database failed", err}
+	}
+
+	results, err := store.ModerateUnverifiedQuotes(IDs, m, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			// the quotes confirmed before were rolled back
+			for i := range results {
+				results[i].Quote = QuoteT{}
+			}
+			return results, nil
+		}
+	}
+
+	// add confirmed quotes to cache
+	if m.Action == ActionConfirm {
+		for _, result := range results {
+			unsafeAddQuoteToCache(result.Quote)
+		}
+		unsafeForceCacheIndexGen()
+	}
+
+	return results, nil
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"golang.org/x/crypto/bcrypt"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                  CONSTANTS                                 */
+/* -------------------------------------------------------------------------- */
+
+// maxPasswordLength is the length in bytes of the longest password bcrypt can hash
+const maxPasswordLength = 72
+
+/* -------------------------------------------------------------------------- */
+/*                          GLOBAL PACKAGE VARIABLES                          */
+/* -------------------------------------------------------------------------- */
+
+// dummyPasswordHash is compared against if no user with the given name exists,
+// so that unknown usernames take as long to reject as wrong passwords
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("quote_gallery"), bcrypt.DefaultCost)
+
+/* -------------------------------------------------------------------------- */
+/*                         UNEXPORTED PASSWORD FUNCTIONS                      */
+/* -------------------------------------------------------------------------- */
+
+// hashPassword returns the bcrypt hash of password, which is stored instead of the password
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// isPasswordHash reports whether s is a bcrypt hash and not a plaintext password
+// from the time before passwords were hashed
+func isPasswordHash(s string) bool {
+	_, err := bcrypt.Cost([]byte(s))
+	return err == nil
+}
+
+// checkPassword compares password with hash in constant time.
+// An empty hash (i.e. no matching user) is compared with dummyPasswordHash and never matches.
+func checkPassword(hash string, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// RateLimitT stores how often each user may do something, as a token bucket
+// Name     what is limited, see the RateLimit constants
+// Burst    how many times it can be done in a row (the size of the bucket)
+// PerHour  how many times per hour it can be done in the long run (the refill rate),
+//          0 means unlimited
+type RateLimitT struct {
+	Name    string
+	Burst   int32
+	PerHour int32
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                  CONSTANTS                                 */
+/* -------------------------------------------------------------------------- */
+
+// The things which are rate limited
+const (
+	// RateLimitSubmissions limits submitting quotes
+	RateLimitSubmissions = "submissions"
+	// RateLimitVotes limits voting for quotes
+	RateLimitVotes = "votes"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                          GLOBAL PACKAGE VARIABLES                          */
+/* -------------------------------------------------------------------------- */
+
+// defaultRateLimits are used until an admin changes them
+var defaultRateLimits = []RateLimitT{
+	{RateLimitSubmissions, 5, 20},
+	{RateLimitVotes, 30, 300},
+}
+
+/* -------------------------------------------------------------------------- */
+/*                        EXPORTED RATE LIMITS FUNCTIONS                      */
+/* -------------------------------------------------------------------------- */
+
+// GetRateLimits returns all rate limits, sorted by Name
+//
+// Possible returned error types: -
+func GetRateLimits() []RateLimitT {
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	limits := make([]RateLimitT, 0, len(cache.rateLimitMap))
+	for _, l := range cache.rateLimitMap {
+		limits = append(limits, l)
+	}
+
+	sort.Slice(limits, func(i, j int) bool { return limits[i].Name < limits[j].Name })
+	return limits
+}
+
+// GetRateLimit returns the rate limit with the given name and whether it exists
+//
+// Possible returned error types: -
+func GetRateLimit(name string) (RateLimitT, bool) {
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	l, ok := cache.rateLimitMap[name]
+	return l, ok
+}
+
+// SetRateLimit overwrites the rate limit with the name l.Name
+//
+// Possible returned error types: generic / DBError
+func SetRateLimit(l RateLimitT) error {
+	if store == nil {
+		return errors.New("SetRateLimit: not connected to database")
+	}
+
+	if l.Burst < 1 {
+		return errors.New("SetRateLimit: Burst must be greater than zero")
+	}
+
+	if l.PerHour < 0 {
+		return errors.New("SetRateLimit: PerHour must not be negative")
+	}
+
+	globalMutex.MajorLock()
+	defer globalMutex.MajorUnlock()
+
+	if _, ok := cache.rateLimitMap[l.Name]; !ok {
+		return fmt.Errorf("SetRateLimit: unknown Name %q", l.Name)
+	}
+
+	// Verify connection to database
+	err := store.Ping()
+	if err != nil {
+		store.Close()
+		return DBError{"SetRateLimit: pinging database failed", err}
+	}
+
+	err = store.PutRateLimit(l)
+	if err != nil {
+		return err
+	}
+
+	cache.rateLimitMap[l.Name] = l
+	return nil
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"errors"
+	"time"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// PasswordResetT stores one password reset link, which an admin hands out to a user
+// UserID   the unique ID of the user whose password can be reset
+// Expires  the unixtime after which the link can no longer be used
+//
+// Like session tokens, the token itself is only shown once when creating the link,
+// the database stores its hash (see hashToken). Each link can only be used once.
+type PasswordResetT struct {
+	UserID  int32
+	Expires int64
+}
+
+/* -------------------------------------------------------------------------- */
+/*                      EXPORTED PASSWORD RESET FUNCTIONS                     */
+/* -------------------------------------------------------------------------- */
+
+// CreatePasswordReset creates a password reset link for the given user which is valid
+// for the given duration, earlier links of the user stop working.
+// It returns the token, which needs to be presented to ResetPassword.
+//
+// Possible returned error types: generic / DBError / InvalidUserIDError
+func CreatePasswordReset(userid int32, duration time.Duration) (string, error) {
+	if store == nil {
+		return "", errors.New("CreatePasswordReset: not connected to database")
+	}
+
+	if userid < 1 {
+		return "", InvalidUserIDError{"CreatePasswordReset: invalid UserID, must be greater than zero"}
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return "", errors.New("CreatePasswordReset: generating token failed: " + err.Error())
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	// Verify connection to database
+	err = store.Ping()
+	if err != nil {
+		return "", DBError{"CreatePasswordReset: pinging database failed", err}
+	}
+
+	err = store.CreatePasswordReset(hashToken(token), PasswordResetT{userid, time.Now().Add(duration).Unix()})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// ResetPassword uses up a password reset token to set a new password and returns the UserID
+// of the user whose password was reset. password is the plaintext password, only its hash is stored.
+// All sessions and API tokens of the user are ended, like in SetUserPassword.
+//
+// Possible returned error types: generic / DBError / InvalidPasswordError /
+// InvalidTokenError (if the token is unknown, expired or was used already)
+func ResetPassword(token string, password string) (int32, error) {
+	if store == nil {
+		return 0, errors.New("ResetPassword: not connected to database")
+	}
+
+	if len(password) > maxPasswordLength {
+		return 0, InvalidPasswordError{"ResetPassword: password is longer than 72 bytes"}
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return 0, errors.New("ResetPassword: hashing password failed: " + err.Error())
+	}
+
+	globalMutex.MajorLock()
+	defer globalMutex.MajorUnlock()
+
+	// Verify connection to database
+	err = store.Ping()
+	if err != nil {
+		store.Close()
+		return 0, DBError{"ResetPassword: pinging database failed", err}
+	}
+
+	userid, err := store.ResetUserPassword(hashToken(token), hash, time.Now().Unix())
+	if err != nil {
+		return 0, err
+	}
+
+	user, ok := unsafeGetUserByIDFromCache(userid)
+	if !ok {
+		return 0, InvalidUserIDError{"ResetPassword: no matching user found"}
+	}
+
+	user.Password = hash
+	return userid, unsafeOverwriteUserInCache(user)
+}
@@ -0,0 +1,71 @@
+package database
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// RoleT is the role of a user, which determines what they are permitted to do
+type RoleT string
+
+// PermissionT is something a user can be permitted to do, see RoleT.Can
+type PermissionT int
+
+/* -------------------------------------------------------------------------- */
+/*                                  CONSTANTS                                 */
+/* -------------------------------------------------------------------------- */
+
+// The roles, each one is permitted everything the previous one is
+const (
+	// RoleUser can view, vote for and submit quotes
+	RoleUser RoleT = "user"
+	// RoleModerator can additionally confirm, edit and delete unverified quotes
+	RoleModerator RoleT = "moderator"
+	// RoleAdmin can additionally manage teachers, users and invites
+	RoleAdmin RoleT = "admin"
+)
+
+// The permissions, named after the role which is granted them first
+const (
+	// PermissionUse allows viewing, voting for and submitting quotes
+	PermissionUse PermissionT = iota
+	// PermissionModerate allows handling unverified quotes
+	PermissionModerate
+	// PermissionAdminister allows managing teachers, users and invites
+	PermissionAdminister
+)
+
+/* -------------------------------------------------------------------------- */
+/*                          GLOBAL PACKAGE VARIABLES                          */
+/* -------------------------------------------------------------------------- */
+
+// rolePermissions contains the permissions of every valid role
+var rolePermissions = map[RoleT][]PermissionT{
+	RoleUser:      {PermissionUse},
+	RoleModerator: {PermissionUse, PermissionModerate},
+	RoleAdmin:     {PermissionUse, PermissionModerate, PermissionAdminister},
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          EXPORTED ROLES FUNCTIONS                          */
+/* -------------------------------------------------------------------------- */
+
+// Roles returns all valid roles, ordered by increasing permissions
+func Roles() []RoleT {
+	return []RoleT{RoleUser, RoleModerator, RoleAdmin}
+}
+
+// IsValid checks if role is one of the defined roles
+func (role RoleT) IsValid() bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Can checks if users with this role have the given permission
+func (role RoleT) Can(permission PermissionT) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
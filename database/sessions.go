@@ -0,0 +1,132 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// SessionT stores one login session
+// UserID   the unique ID of the logged in user
+// Expires  the unixtime after which the session is no longer valid
+//
+// The session token itself is only handed to the client,
+// the database stores its hash (see hashToken)
+type SessionT struct {
+	UserID  int32
+	Expires int64
+}
+
+/* -------------------------------------------------------------------------- */
+/*                         EXPORTED SESSIONS FUNCTIONS                        */
+/* -------------------------------------------------------------------------- */
+
+// CreateSession starts a new session for the given user which is valid for the given duration.
+// It returns the session token, which needs to be presented to GetSessionUserID.
+// Expired sessions of all users are deleted on the way.
+//
+// Possible returned error types: generic / DBError
+func CreateSession(userid int32, duration time.Duration) (string, error) {
+	if store == nil {
+		return "", errors.New("CreateSession: not connected to database")
+	}
+
+	if userid < 1 {
+		return "", errors.New("CreateSession: invalid UserID, must be greater than zero")
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return "", errors.New("CreateSession: generating token failed: " + err.Error())
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	// Verify connection to database
+	err = store.Ping()
+	if err != nil {
+		return "", DBError{"CreateSession: pinging database failed", err}
+	}
+
+	now := time.Now()
+
+	err = store.DeleteExpiredSessions(now.Unix())
+	if err != nil {
+		return "", err
+	}
+
+	err = store.CreateSession(hashToken(token), SessionT{userid, now.Add(duration).Unix()})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// GetSessionUserID returns the UserID of the session with the given token
+// or 0 if there is no such session, it has expired or its user has been disabled
+//
+// Possible returned error types: -
+func GetSessionUserID(token string) int32 {
+	if store == nil || token == "" {
+		return 0
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	session, err := store.GetSession(hashToken(token))
+	if err != nil || session.Expires < time.Now().Unix() {
+		return 0
+	}
+
+	user, ok := unsafeGetUserByIDFromCache(session.UserID)
+	if !ok || user.Disabled {
+		return 0
+	}
+
+	return session.UserID
+}
+
+// DeleteSession ends the session with the given token
+//
+// Possible returned error types: generic / DBError / InvalidTokenError
+func DeleteSession(token string) error {
+	if store == nil {
+		return errors.New("DeleteSession: not connected to database")
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	return store.DeleteSession(hashToken(token))
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              HELPER FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+// generateToken returns a random, url safe token with 256 bits of entropy
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hash under which a token is stored.
+// Tokens are random enough that an unsalted, fast hash is sufficient.
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -18,6 +18,15 @@ func (err InvalidTeacherIDError) Error() string {
 	return err.Message
 }
 
+// TeacherInUseError is used when a teacher is not deleted, because quotes are still assigned to it
+type TeacherInUseError struct {
+	Message string
+}
+
+func (err TeacherInUseError) Error() string {
+	return err.Message
+}
+
 // InvalidQuoteIDError is used when the QuoteID is invalid
 type InvalidQuoteIDError struct {
 	Message string
@@ -39,3 +48,48 @@ func (err DBError) Error() string {
 	}
 	return err.Message
 }
+
+// InvalidTokenError is used when a token (e.g. of a session) is unknown or expired
+type InvalidTokenError struct {
+	Message string
+}
+
+func (err InvalidTokenError) Error() string {
+	return err.Message
+}
+
+// InvalidUserNameError is used when a user name is empty or already taken
+type InvalidUserNameError struct {
+	Message string
+}
+
+func (err InvalidUserNameError) Error() string {
+	return err.Message
+}
+
+// InvalidPasswordError is used when a password can't be stored, because it is too long
+type InvalidPasswordError struct {
+	Message string
+}
+
+func (err InvalidPasswordError) Error() string {
+	return err.Message
+}
+
+// InvalidInviteIDError is used when the InviteID is invalid
+type InvalidInviteIDError struct {
+	Message string
+}
+
+func (err InvalidInviteIDError) Error() string {
+	return err.Message
+}
+
+// InvalidTokenIDError is used when the TokenID is invalid
+type InvalidTokenIDError struct {
+	Message string
+}
+
+func (err InvalidTokenIDError) Error() string {
+	return err.Message
+}
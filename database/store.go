@@ -0,0 +1,193 @@
+package database
+
+import (
+	"fmt"
+	"os"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// Store is the long time storage behind the cache.
+// Every backend (e.g. PostgreSQL) implements this interface, the rest of the
+// database package only talks to the store through it.
+//
+// Implementations must be safe for concurrent use, because operations that don't
+// touch the cache only hold a MinorLock of the globalMutex.
+//
+// Implementations return the error types defined in status.go:
+// InvalidTeacherIDError if a referenced teacher doesn't exist,
+// InvalidQuoteIDError if a referenced (unverified) quote doesn't exist,
+// InvalidUserIDError if a referenced user doesn't exist and
+// DBError for everything else.
+type Store interface {
+	// Ping verifies the connection to the store
+	Ping() error
+	// Close closes the connection to the store
+	Close() error
+	// Migrate brings the schema up to date, see migrate.go
+	Migrate() error
+
+	/* --------------------------------- QUOTES --------------------------------- */
+
+	// GetQuotes returns all quotes, Stats, MyVote and Match are left empty
+	GetQuotes() ([]QuoteT, error)
+	// CreateQuote stores a new quote, q.QuoteID is ignored and the new QuoteID is returned
+	CreateQuote(q QuoteT) (int32, error)
+	// UpdateQuote overwrites TeacherID, Context and Text of the quote with q.QuoteID
+	UpdateQuote(q QuoteT) error
+	// DeleteQuote deletes a quote and all of its votes
+	DeleteQuote(ID int32) error
+
+	/* -------------------------------- TEACHERS -------------------------------- */
+
+	// GetTeachers returns all teachers
+	GetTeachers() ([]TeacherT, error)
+	// CreateTeacher stores a new teacher, t.TeacherID is ignored and the new TeacherID is returned
+	CreateTeacher(t TeacherT) (int32, error)
+	// UpdateTeacher overwrites Name, Title and Note of the teacher with t.TeacherID
+	UpdateTeacher(t TeacherT) error
+	// DeleteTeacher deletes a teacher and, if cascade is set, all of its (unverified) quotes.
+	// Otherwise a teacher with (unverified) quotes is not deleted.
+	DeleteTeacher(ID int32, cascade bool) error
+	// MergeTeachers assigns all (unverified and rejected) quotes of the teacher with otherID
+	// to the teacher with ID and deletes the teacher with otherID,
+	// InvalidTeacherIDError is returned if ID and otherID are the same
+	MergeTeachers(ID int32, otherID int32) error
+
+	/* ---------------------------- UNVERIFIED QUOTES --------------------------- */
+
+	// GetUnverifiedQuotes returns all unverified quotes
+	GetUnverifiedQuotes() ([]UnverifiedQuoteT, error)
+	// GetUnverifiedQuoteByID returns the unverified quote with the given QuoteID
+	GetUnverifiedQuoteByID(ID int32) (UnverifiedQuoteT, error)
+	// CreateUnverifiedQuote stores a new unverified quote, TeacherID 0 is stored as no teacher,
+	// q.QuoteID is ignored and the new QuoteID is returned
+	CreateUnverifiedQuote(q UnverifiedQuoteT) (int32, error)
+	// UpdateUnverifiedQuote overwrites TeacherID, TeacherName, Context and Text
+	// of the unverified quote with q.QuoteID
+	UpdateUnverifiedQuote(q UnverifiedQuoteT) error
+	// DeleteUnverifiedQuote deletes an unverified quote
+	DeleteUnverifiedQuote(ID int32) error
+	// ConfirmUnverifiedQuote atomically moves an unverified quote to the quotes,
+	// remembering its submitter, returns the new quote (Stats, MyVote and Match left empty)
+	// and InvalidTeacherIDError if the unverified quote has no TeacherID
+	ConfirmUnverifiedQuote(ID int32) (QuoteT, error)
+	// RejectUnverifiedQuote atomically moves an unverified quote to the rejected quotes
+	// with the given reason, note and unixtime of the rejection
+	RejectUnverifiedQuote(ID int32, reason ReasonT, note string, now int64) error
+	// ModerateUnverifiedQuotes atomically applies m to the unverified quotes with the given QuoteIDs
+	// and returns one result per QuoteID. If the action fails for any of them, nothing is changed.
+	ModerateUnverifiedQuotes(IDs []int32, m ModerationT, now int64) ([]ModerationResultT, error)
+
+	/* ------------------------------- SUBMISSIONS ------------------------------ */
+
+	// GetSubmissions returns all unverified, rejected and confirmed quotes submitted
+	// by the user with the given UserID, confirmed ones as they are now
+	GetSubmissions(userID int32) ([]SubmissionT, error)
+
+	/* ---------------------------------- USERS --------------------------------- */
+
+	// GetUsers returns all users
+	GetUsers() ([]UserT, error)
+	// GetUsernameByID returns the name of the user with the given UserID
+	GetUsernameByID(ID int32) (string, error)
+	// CreateUser stores a new user, u.UserID is ignored and the new UserID is returned
+	CreateUser(u UserT) (int32, error)
+	// UpdateUserPassword overwrites the (hashed) password of the user with the given UserID
+	UpdateUserPassword(ID int32, password string) error
+	// UpdateUser atomically overwrites Name, Role and Disabled of the user with u.UserID,
+	// the (hashed) Password too unless u.Password is empty, in which case all API tokens
+	// of the user are deleted, and deletes all sessions of the user if endSessions is set
+	UpdateUser(u UserT, endSessions bool) error
+	// DeleteUser deletes a user together with their unverified and rejected quotes,
+	// votes, sessions and API tokens, confirmed quotes lose their submitter
+	DeleteUser(ID int32) error
+
+	/* -------------------------------- SESSIONS -------------------------------- */
+
+	// CreateSession stores a new session under the hash of its token
+	CreateSession(tokenHash string, session SessionT) error
+	// GetSession returns the session stored under the given token hash
+	GetSession(tokenHash string) (SessionT, error)
+	// DeleteSession deletes the session stored under the given token hash
+	DeleteSession(tokenHash string) error
+	// DeleteExpiredSessions deletes all sessions which expired before the given unixtime
+	DeleteExpiredSessions(now int64) error
+
+	/* --------------------------------- INVITES -------------------------------- */
+
+	// GetInvites returns all invites
+	GetInvites() ([]InviteT, error)
+	// CreateInvite stores a new invite under the hash of its code,
+	// inv.InviteID and inv.Uses are ignored and the new InviteID is returned
+	CreateInvite(codeHash string, inv InviteT) (int32, error)
+	// DeleteInvite deletes an invite
+	DeleteInvite(ID int32) error
+	// CreateUserWithInvite atomically uses up one use of the invite stored under
+	// the given code hash and stores the new user with the invite's Role.
+	// It returns the new user and InvalidTokenError if the invite doesn't exist,
+	// expired before now or has no uses left.
+	CreateUserWithInvite(codeHash string, u UserT, now int64) (UserT, error)
+
+	/* ----------------------------- PASSWORD RESETS ---------------------------- */
+
+	// CreatePasswordReset stores a new password reset under the hash of its token,
+	// replacing any earlier password reset of the same user
+	CreatePasswordReset(tokenHash string, reset PasswordResetT) error
+	// ResetUserPassword atomically uses up the password reset stored under the given
+	// token hash, replaces the user's password and deletes all sessions and API tokens of the user.
+	// It returns the UserID and InvalidTokenError if the password reset doesn't exist
+	// or expired before now.
+	ResetUserPassword(tokenHash string, password string, now int64) (int32, error)
+
+	/* --------------------------------- TOKENS --------------------------------- */
+
+	// GetTokens returns all API tokens of the user with the given UserID
+	GetTokens(userID int32) ([]TokenT, error)
+	// GetToken returns the API token stored under the given token hash
+	GetToken(tokenHash string) (TokenT, error)
+	// CreateToken stores a new API token under the hash of the token,
+	// t.TokenID is ignored and the new TokenID is returned
+	CreateToken(tokenHash string, t TokenT) (int32, error)
+	// DeleteToken deletes an API token, but only if it belongs to the user with the given UserID
+	DeleteToken(userID int32, ID int32) error
+
+	/* ------------------------------- RATE LIMITS ------------------------------ */
+
+	// GetRateLimits returns all rate limits which have been changed from their default
+	GetRateLimits() ([]RateLimitT, error)
+	// PutRateLimit stores a rate limit, an existing one with the same Name is overwritten
+	PutRateLimit(l RateLimitT) error
+
+	/* ---------------------------------- VOTES --------------------------------- */
+
+	// GetVotes returns all votes
+	GetVotes() ([]VoteT, error)
+	// PutVote stores a vote, an existing vote of the same user for the same quote is overwritten
+	PutVote(vote VoteT) error
+	// DeleteVote deletes the vote of a user for a quote, it is no error if there is none
+	DeleteVote(userID int32, quoteID int32) error
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              GENERAL FUNCTIONS                             */
+/* -------------------------------------------------------------------------- */
+
+// openStore opens the store selected by the DB_DRIVER environment variable
+// postgres  PostgreSQL, see openPostgresStore (default)
+// sqlite    SQLite, see openSQLiteStore
+// memory    no database at all, see openMemoryStore
+func openStore() (Store, error) {
+	switch driver := os.Getenv("DB_DRIVER"); driver {
+	case "", "postgres":
+		return openPostgresStore()
+	case "sqlite":
+		return openSQLiteStore()
+	case "memory":
+		return openMemoryStore()
+	default:
+		return nil, fmt.Errorf("openStore: unknown DB_DRIVER %q", driver)
+	}
+}
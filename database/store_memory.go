@@ -0,0 +1,1039 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// memoryStore implements Store without any database, everything is lost on exit.
+// It is meant for tests and demos and mimics the behaviour of the SQL tables:
+// IDs are never reused, foreign keys are checked and deletions cascade.
+//
+// The slices are kept in insertion order, votes are stored by voteHash,
+// sessions and invites by the hash of their token or code.
+// submitters maps the QuoteIDs of confirmed quotes to the UserIDs of their submitters,
+// like the UserID column of the quotes table.
+type memoryStore struct {
+	mutex sync.Mutex
+
+	quotes           []QuoteT
+	teachers         []TeacherT
+	unverifiedQuotes []UnverifiedQuoteT
+	rejectedQuotes   []RejectedQuoteT
+	users            []UserT
+	votes            map[int64]VoteT
+	sessions         map[string]SessionT
+	invites          map[string]InviteT
+	tokens           map[string]TokenT
+	passwordResets   map[string]PasswordResetT
+	rateLimits       map[string]RateLimitT
+	submitters       map[int32]int32
+
+	// last IDs handed out, used like serial columns
+	lastQuoteID           int32
+	lastTeacherID         int32
+	lastUnverifiedQuoteID int32
+	lastUserID            int32
+	lastInviteID          int32
+	lastTokenID           int32
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              GENERAL FUNCTIONS                             */
+/* -------------------------------------------------------------------------- */
+
+// openMemoryStore creates an empty memoryStore.
+// If the DB_SEED environment variable is set, it is filled with demo data, see seed.
+func openMemoryStore() (Store, error) {
+	s := &memoryStore{
+		votes:          make(map[int64]VoteT),
+		sessions:       make(map[string]SessionT),
+		invites:        make(map[string]InviteT),
+		tokens:         make(map[string]TokenT),
+		passwordResets: make(map[string]PasswordResetT),
+		rateLimits:     make(map[string]RateLimitT),
+		submitters:     make(map[int32]int32),
+	}
+
+	if os.Getenv("DB_SEED") != "" {
+		err := s.seed()
+		if err != nil {
+			return nil, err
+		}
+		log.Print("Seeded memory store with demo data, log in as admin/admin, moderator/moderator or user/user")
+	}
+
+	return s, nil
+}
+
+func (s *memoryStore) Ping() error {
+	return nil
+}
+
+// Close doesn't discard anything, so that the store survives a reconnect
+func (s *memoryStore) Close() error {
+	return nil
+}
+
+// Migrate does nothing, the memory store has no schema
+func (s *memoryStore) Migrate() error {
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              QUOTES FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+func (s *memoryStore) GetQuotes() ([]QuoteT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	quotes := make([]QuoteT, len(s.quotes))
+	copy(quotes, s.quotes)
+	return quotes, nil
+}
+
+func (s *memoryStore) CreateQuote(q QuoteT) (int32, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.teacherIndex(q.TeacherID) < 0 {
+		return 0, InvalidTeacherIDError{"CreateQuote: no teacher with given TeacherID"}
+	}
+
+	s.lastQuoteID++
+	s.quotes = append(s.quotes, QuoteT{
+		QuoteID:   s.lastQuoteID,
+		TeacherID: q.TeacherID,
+		Context:   q.Context,
+		Text:      q.Text,
+		Unixtime:  q.Unixtime,
+	})
+	return s.lastQuoteID, nil
+}
+
+func (s *memoryStore) UpdateQuote(q QuoteT) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.teacherIndex(q.TeacherID) < 0 {
+		return InvalidTeacherIDError{"UpdateQuote: no teacher with given TeacherID"}
+	}
+
+	i := s.quoteIndex(q.QuoteID)
+	if i < 0 {
+		return InvalidQuoteIDError{"UpdateQuote: no matching database row found"}
+	}
+
+	s.quotes[i].TeacherID = q.TeacherID
+	s.quotes[i].Context = q.Context
+	s.quotes[i].Text = q.Text
+	return nil
+}
+
+func (s *memoryStore) DeleteQuote(ID int32) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.quoteIndex(ID) < 0 {
+		return InvalidQuoteIDError{"DeleteQuote: no matching database row found"}
+	}
+
+	s.deleteQuote(ID)
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              TEACHERS FUNCTIONS                            */
+/* -------------------------------------------------------------------------- */
+
+func (s *memoryStore) GetTeachers() ([]TeacherT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	teachers := make([]TeacherT, len(s.teachers))
+	copy(teachers, s.teachers)
+	return teachers, nil
+}
+
+func (s *memoryStore) CreateTeacher(t TeacherT) (int32, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastTeacherID++
+	t.TeacherID = s.lastTeacherID
+	s.teachers = append(s.teachers, t)
+	return t.TeacherID, nil
+}
+
+func (s *memoryStore) UpdateTeacher(t TeacherT) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i := s.teacherIndex(t.TeacherID)
+	if i < 0 {
+		return InvalidTeacherIDError{"UpdateTeacher: no matching database row found"}
+	}
+
+	s.teachers[i] = t
+	return nil
+}
+
+func (s *memoryStore) DeleteTeacher(ID int32, cascade bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i := s.teacherIndex(ID)
+	if i < 0 {
+		return InvalidTeacherIDError{"DeleteTeacher: no matching database row found"}
+	}
+
+	if !cascade {
+		for _, q := range s.quotes {
+			if q.TeacherID == ID {
+				return TeacherInUseError{"DeleteTeacher: teacher still has (unverified) quotes"}
+			}
+		}
+		for _, q := range s.unverifiedQuotes {
+			if q.TeacherID == ID {
+				return TeacherInUseError{"DeleteTeacher: teacher still has (unverified) quotes"}
+			}
+		}
+	}
+
+	s.teachers = append(s.teachers[:i], s.teachers[i+1:]...)
+
+	// ON DELETE CASCADE
+	for _, q := range append([]QuoteT(nil), s.quotes...) {
+		if q.TeacherID == ID {
+			s.deleteQuote(q.QuoteID)
+		}
+	}
+
+	unverifiedQuotes := s.unverifiedQuotes[:0]
+	for _, q := range s.unverifiedQuotes {
+		if q.TeacherID != ID {
+			unverifiedQuotes = append(unverifiedQuotes, q)
+		}
+	}
+	s.unverifiedQuotes = unverifiedQuotes
+
+	// ON DELETE SET NULL
+	for j := range s.rejectedQuotes {
+		if s.rejectedQuotes[j].TeacherID == ID {
+			s.rejectedQuotes[j].TeacherID = 0
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStore) MergeTeachers(ID int32, otherID int32) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if ID == otherID {
+		return InvalidTeacherIDError{"MergeTeachers: cannot merge a teacher with itself"}
+	}
+
+	if s.teacherIndex(ID) < 0 {
+		return InvalidTeacherIDError{"MergeTeachers: no teacher with given TeacherID"}
+	}
+
+	i := s.teacherIndex(otherID)
+	if i < 0 {
+		return InvalidTeacherIDError{"MergeTeachers: no teacher with given other TeacherID"}
+	}
+
+	for j := range s.quotes {
+		if s.quotes[j].TeacherID == otherID {
+			s.quotes[j].TeacherID = ID
+		}
+	}
+	for j := range s.unverifiedQuotes {
+		if s.unverifiedQuotes[j].TeacherID == otherID {
+			s.unverifiedQuotes[j].TeacherID = ID
+		}
+	}
+	for j := range s.rejectedQuotes {
+		if s.rejectedQuotes[j].TeacherID == otherID {
+			s.rejectedQuotes[j].TeacherID = ID
+		}
+	}
+
+	s.teachers = append(s.teachers[:i], s.teachers[i+1:]...)
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          UNVERIFIED QUOTES FUNCTIONS                       */
+/* -------------------------------------------------------------------------- */
+
+func (s *memoryStore) GetUnverifiedQuotes() ([]UnverifiedQuoteT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	quotes := make([]UnverifiedQuoteT, len(s.unverifiedQuotes))
+	copy(quotes, s.unverifiedQuotes)
+	return quotes, nil
+}
+
+func (s *memoryStore) GetUnverifiedQuoteByID(ID int32) (UnverifiedQuoteT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i := s.unverifiedQuoteIndex(ID)
+	if i < 0 {
+		return UnverifiedQuoteT{}, InvalidQuoteIDError{"GetUnverifiedQuoteByID: no matching database row found"}
+	}
+	return s.unverifiedQuotes[i], nil
+}
+
+func (s *memoryStore) CreateUnverifiedQuote(q UnverifiedQuoteT) (int32, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.userIndex(q.UserID) < 0 {
+		return 0, InvalidUserIDError{"CreateUnverifiedQuote: no user with given UserID"}
+	}
+
+	// TeacherID 0 is stored as no teacher
+	if q.TeacherID != 0 && s.teacherIndex(q.TeacherID) < 0 {
+		return 0, InvalidTeacherIDError{"CreateUnverifiedQuote: no teacher with given TeacherID"}
+	}
+
+	s.lastUnverifiedQuoteID++
+	q.QuoteID = s.lastUnverifiedQuoteID
+	s.unverifiedQuotes = append(s.unverifiedQuotes, q)
+	return q.QuoteID, nil
+}
+
+func (s *memoryStore) UpdateUnverifiedQuote(q UnverifiedQuoteT) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if q.TeacherID != 0 && s.teacherIndex(q.TeacherID) < 0 {
+		return InvalidTeacherIDError{"UpdateUnverifiedQuote: no teacher with given TeacherID"}
+	}
+
+	i := s.unverifiedQuoteIndex(q.QuoteID)
+	if i < 0 {
+		return InvalidQuoteIDError{"UpdateUnverifiedQuote: no matching database row found"}
+	}
+
+	s.unverifiedQuotes[i].TeacherID = q.TeacherID
+	s.unverifiedQuotes[i].TeacherName = q.TeacherName
+	s.unverifiedQuotes[i].Context = q.Context
+	s.unverifiedQuotes[i].Text = q.Text
+	return nil
+}
+
+func (s *memoryStore) DeleteUnverifiedQuote(ID int32) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i := s.unverifiedQuoteIndex(ID)
+	if i < 0 {
+		return InvalidQuoteIDError{"DeleteUnverifiedQuote: no matching database row found"}
+	}
+
+	s.unverifiedQuotes = append(s.unverifiedQuotes[:i], s.unverifiedQuotes[i+1:]...)
+	return nil
+}
+
+func (s *memoryStore) ConfirmUnverifiedQuote(ID int32) (QuoteT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.confirmUnverifiedQuote(ID)
+}
+
+func (s *memoryStore) RejectUnverifiedQuote(ID int32, reason ReasonT, note string, now int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.rejectUnverifiedQuote(ID, reason, note, now)
+}
+
+func (s *memoryStore) ModerateUnverifiedQuotes(IDs []int32, m ModerationT, now int64) ([]ModerationResultT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// copies to roll back to, removing from a slice overwrites its backing array
+	quotes := append([]QuoteT(nil), s.quotes...)
+	unverifiedQuotes := append([]UnverifiedQuoteT(nil), s.unverifiedQuotes...)
+	rejectedQuotes := append([]RejectedQuoteT(nil), s.rejectedQuotes...)
+	submitters := make(map[int32]int32, len(s.submitters))
+	for quoteID, userID := range s.submitters {
+		submitters[quoteID] = userID
+	}
+	lastQuoteID := s.lastQuoteID
+
+	results := make([]ModerationResultT, len(IDs))
+	failed := false
+	for i, ID := range IDs {
+		var err error
+		results[i].QuoteID = ID
+
+		switch m.Action {
+		case ActionConfirm:
+			results[i].Quote, err = s.confirmUnverifiedQuote(ID)
+		case ActionReject:
+			err = s.rejectUnverifiedQuote(ID, m.Reason, m.Note, now)
+		case ActionAssignTeacher:
+			err = s.assignTeacherToUnverifiedQuote(ID, m.TeacherID)
+		default:
+			err = fmt.Errorf("ModerateUnverifiedQuotes: invalid Action %q", m.Action)
+		}
+
+		switch err.(type) {
+		case nil:
+		case InvalidQuoteIDError, InvalidTeacherIDError:
+			results[i].Err = err
+			failed = true
+		default:
+			s.quotes, s.unverifiedQuotes, s.rejectedQuotes, s.submitters, s.lastQuoteID = quotes, unverifiedQuotes, rejectedQuotes, submitters, lastQuoteID
+			return nil, err
+		}
+	}
+
+	// nothing is changed unless the action succeeded for all unverified quotes
+	if failed {
+		s.quotes, s.unverifiedQuotes, s.rejectedQuotes, s.submitters, s.lastQuoteID = quotes, unverifiedQuotes, rejectedQuotes, submitters, lastQuoteID
+	}
+	return results, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                             SUBMISSIONS FUNCTIONS                          */
+/* -------------------------------------------------------------------------- */
+
+func (s *memoryStore) GetSubmissions(userID int32) ([]SubmissionT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var submissions []SubmissionT
+	for _, q := range s.unverifiedQuotes {
+		if q.UserID == userID {
+			submissions = append(submissions, SubmissionT{State: SubmissionPending, UnverifiedQuoteT: q})
+		}
+	}
+
+	for _, q := range s.rejectedQuotes {
+		if q.UserID == userID {
+			submissions = append(submissions, SubmissionT{SubmissionRejected, q.UnverifiedQuoteT, q.Reason, q.Note})
+		}
+	}
+
+	for _, q := range s.quotes {
+		if s.submitters[q.QuoteID] == userID {
+			submissions = append(submissions, SubmissionT{
+				State: SubmissionConfirmed,
+				UnverifiedQuoteT: UnverifiedQuoteT{
+					UserID:    userID,
+					QuoteID:   q.QuoteID,
+					TeacherID: q.TeacherID,
+					Context:   q.Context,
+					Text:      q.Text,
+					Unixtime:  q.Unixtime,
+				},
+			})
+		}
+	}
+
+	return submissions, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               USERS FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+func (s *memoryStore) GetUsers() ([]UserT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	users := make([]UserT, len(s.users))
+	copy(users, s.users)
+	return users, nil
+}
+
+func (s *memoryStore) GetUsernameByID(ID int32) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i := s.userIndex(ID)
+	if i < 0 {
+		return "", InvalidUserIDError{"GetUsernameByID: no matching user found"}
+	}
+	return s.users[i].Name, nil
+}
+
+func (s *memoryStore) CreateUser(u UserT) (int32, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastUserID++
+	u.UserID = s.lastUserID
+	s.users = append(s.users, u)
+	return u.UserID, nil
+}
+
+func (s *memoryStore) UpdateUserPassword(ID int32, password string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i := s.userIndex(ID)
+	if i < 0 {
+		return InvalidUserIDError{"UpdateUserPassword: no matching database row found"}
+	}
+
+	s.users[i].Password = password
+	return nil
+}
+
+func (s *memoryStore) UpdateUser(u UserT, endSessions bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i := s.userIndex(u.UserID)
+	if i < 0 {
+		return InvalidUserIDError{"UpdateUser: no matching database row found"}
+	}
+
+	s.users[i].Name = u.Name
+	s.users[i].Role = u.Role
+	s.users[i].Disabled = u.Disabled
+	if u.Password != "" {
+		s.users[i].Password = u.Password
+
+		for tokenHash, t := range s.tokens {
+			if t.UserID == u.UserID {
+				delete(s.tokens, tokenHash)
+			}
+		}
+	}
+
+	if endSessions {
+		for tokenHash, session := range s.sessions {
+			if session.UserID == u.UserID {
+				delete(s.sessions, tokenHash)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) DeleteUser(ID int32) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i := s.userIndex(ID)
+	if i < 0 {
+		return InvalidUserIDError{"DeleteUser: no matching database row found"}
+	}
+
+	s.users = append(s.users[:i], s.users[i+1:]...)
+
+	// ON DELETE CASCADE
+	unverifiedQuotes := s.unverifiedQuotes[:0]
+	for _, q := range s.unverifiedQuotes {
+		if q.UserID != ID {
+			unverifiedQuotes = append(unverifiedQuotes, q)
+		}
+	}
+	s.unverifiedQuotes = unverifiedQuotes
+
+	rejectedQuotes := s.rejectedQuotes[:0]
+	for _, q := range s.rejectedQuotes {
+		if q.UserID != ID {
+			rejectedQuotes = append(rejectedQuotes, q)
+		}
+	}
+	s.rejectedQuotes = rejectedQuotes
+
+	// ON DELETE SET NULL
+	for quoteID, userID := range s.submitters {
+		if userID == ID {
+			delete(s.submitters, quoteID)
+		}
+	}
+
+	for hash, vote := range s.votes {
+		if vote.UserID == ID {
+			delete(s.votes, hash)
+		}
+	}
+
+	for tokenHash, session := range s.sessions {
+		if session.UserID == ID {
+			delete(s.sessions, tokenHash)
+		}
+	}
+
+	for tokenHash, t := range s.tokens {
+		if t.UserID == ID {
+			delete(s.tokens, tokenHash)
+		}
+	}
+
+	for tokenHash, reset := range s.passwordResets {
+		if reset.UserID == ID {
+			delete(s.passwordResets, tokenHash)
+		}
+	}
+
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              SESSIONS FUNCTIONS                            */
+/* -------------------------------------------------------------------------- */
+
+func (s *memoryStore) CreateSession(tokenHash string, session SessionT) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.userIndex(session.UserID) < 0 {
+		return InvalidUserIDError{"CreateSession: no user with given UserID"}
+	}
+
+	s.sessions[tokenHash] = session
+	return nil
+}
+
+func (s *memoryStore) GetSession(tokenHash string) (SessionT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, ok := s.sessions[tokenHash]
+	if !ok {
+		return SessionT{}, InvalidTokenError{"GetSession: no matching session found"}
+	}
+	return session, nil
+}
+
+func (s *memoryStore) DeleteSession(tokenHash string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.sessions[tokenHash]; !ok {
+		return InvalidTokenError{"DeleteSession: no matching session found"}
+	}
+
+	delete(s.sessions, tokenHash)
+	return nil
+}
+
+func (s *memoryStore) DeleteExpiredSessions(now int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for tokenHash, session := range s.sessions {
+		if session.Expires < now {
+			delete(s.sessions, tokenHash)
+		}
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              INVITES FUNCTIONS                             */
+/* -------------------------------------------------------------------------- */
+
+func (s *memoryStore) GetInvites() ([]InviteT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	invites := make([]InviteT, 0, len(s.invites))
+	for _, inv := range s.invites {
+		invites = append(invites, inv)
+	}
+	return invites, nil
+}
+
+func (s *memoryStore) CreateInvite(codeHash string, inv InviteT) (int32, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastInviteID++
+	inv.InviteID = s.lastInviteID
+	inv.Uses = 0
+	s.invites[codeHash] = inv
+	return inv.InviteID, nil
+}
+
+func (s *memoryStore) DeleteInvite(ID int32) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for codeHash, inv := range s.invites {
+		if inv.InviteID == ID {
+			delete(s.invites, codeHash)
+			return nil
+		}
+	}
+	return InvalidInviteIDError{"DeleteInvite: no matching database row found"}
+}
+
+func (s *memoryStore) CreateUserWithInvite(codeHash string, u UserT, now int64) (UserT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	inv, ok := s.invites[codeHash]
+	if !ok || inv.Uses >= inv.MaxUses || inv.Expires < now {
+		return UserT{}, InvalidTokenError{"CreateUserWithInvite: invite doesn't exist, expired or is used up"}
+	}
+
+	inv.Uses++
+	s.invites[codeHash] = inv
+
+	s.lastUserID++
+	u.UserID = s.lastUserID
+	u.Role = inv.Role
+	s.users = append(s.users, u)
+	return u, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          PASSWORD RESETS FUNCTIONS                         */
+/* -------------------------------------------------------------------------- */
+
+func (s *memoryStore) CreatePasswordReset(tokenHash string, reset PasswordResetT) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.userIndex(reset.UserID) < 0 {
+		return InvalidUserIDError{"CreatePasswordReset: no user with given UserID"}
+	}
+
+	for h, r := range s.passwordResets {
+		if r.UserID == reset.UserID {
+			delete(s.passwordResets, h)
+		}
+	}
+
+	s.passwordResets[tokenHash] = reset
+	return nil
+}
+
+func (s *memoryStore) ResetUserPassword(tokenHash string, password string, now int64) (int32, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	reset, ok := s.passwordResets[tokenHash]
+	if !ok || reset.Expires < now {
+		return 0, InvalidTokenError{"ResetUserPassword: password reset doesn't exist or expired"}
+	}
+	delete(s.passwordResets, tokenHash)
+
+	i := s.userIndex(reset.UserID)
+	if i < 0 {
+		return 0, InvalidUserIDError{"ResetUserPassword: no matching database row found"}
+	}
+	s.users[i].Password = password
+
+	for h, session := range s.sessions {
+		if session.UserID == reset.UserID {
+			delete(s.sessions, h)
+		}
+	}
+
+	for tokenHash, t := range s.tokens {
+		if t.UserID == reset.UserID {
+			delete(s.tokens, tokenHash)
+		}
+	}
+
+	return reset.UserID, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              TOKENS FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+func (s *memoryStore) GetTokens(userID int32) ([]TokenT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var tokens []TokenT
+	for _, t := range s.tokens {
+		if t.UserID == userID {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens, nil
+}
+
+func (s *memoryStore) GetToken(tokenHash string) (TokenT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	t, ok := s.tokens[tokenHash]
+	if !ok {
+		return TokenT{}, InvalidTokenError{"GetToken: no matching token found"}
+	}
+	return t, nil
+}
+
+func (s *memoryStore) CreateToken(tokenHash string, t TokenT) (int32, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.userIndex(t.UserID) < 0 {
+		return 0, InvalidUserIDError{"CreateToken: no user with given UserID"}
+	}
+
+	s.lastTokenID++
+	t.TokenID = s.lastTokenID
+	s.tokens[tokenHash] = t
+	return t.TokenID, nil
+}
+
+func (s *memoryStore) DeleteToken(userID int32, ID int32) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for tokenHash, t := range s.tokens {
+		if t.TokenID == ID && t.UserID == userID {
+			delete(s.tokens, tokenHash)
+			return nil
+		}
+	}
+	return InvalidTokenIDError{"DeleteToken: no matching database row found"}
+}
+
+/* -------------------------------------------------------------------------- */
+/*                            RATE LIMITS FUNCTIONS                           */
+/* -------------------------------------------------------------------------- */
+
+func (s *memoryStore) GetRateLimits() ([]RateLimitT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	limits := make([]RateLimitT, 0, len(s.rateLimits))
+	for _, l := range s.rateLimits {
+		limits = append(limits, l)
+	}
+	return limits, nil
+}
+
+func (s *memoryStore) PutRateLimit(l RateLimitT) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.rateLimits[l.Name] = l
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               VOTES FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+func (s *memoryStore) GetVotes() ([]VoteT, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	votes := make([]VoteT, 0, len(s.votes))
+	for _, vote := range s.votes {
+		votes = append(votes, vote)
+	}
+	return votes, nil
+}
+
+func (s *memoryStore) PutVote(vote VoteT) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.quoteIndex(vote.QuoteID) < 0 {
+		return InvalidQuoteIDError{"PutVote: QuoteID unknown"}
+	}
+
+	if s.userIndex(vote.UserID) < 0 {
+		return InvalidUserIDError{"PutVote: UserID unknown"}
+	}
+
+	s.votes[voteHash(vote)] = vote
+	return nil
+}
+
+func (s *memoryStore) DeleteVote(userID int32, quoteID int32) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.votes, voteHash(VoteT{UserID: userID, QuoteID: quoteID}))
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              HELPER FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+// The index functions return the slice index of the entry with the given ID or -1.
+// They must only be called while s.mutex is locked.
+
+func (s *memoryStore) quoteIndex(ID int32) int {
+	for i, q := range s.quotes {
+		if q.QuoteID == ID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *memoryStore) teacherIndex(ID int32) int {
+	for i, t := range s.teachers {
+		if t.TeacherID == ID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *memoryStore) unverifiedQuoteIndex(ID int32) int {
+	for i, q := range s.unverifiedQuotes {
+		if q.QuoteID == ID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *memoryStore) userIndex(ID int32) int {
+	for i, u := range s.users {
+		if u.UserID == ID {
+			return i
+		}
+	}
+	return -1
+}
+
+// confirmUnverifiedQuote moves an unverified quote to the quotes,
+// it must only be called while s.mutex is locked
+func (s *memoryStore) confirmUnverifiedQuote(ID int32) (QuoteT, error) {
+	i := s.unverifiedQuoteIndex(ID)
+	if i < 0 {
+		return QuoteT{}, InvalidQuoteIDError{"ConfirmUnverifiedQuote: no matching database row found"}
+	}
+
+	u := s.unverifiedQuotes[i]
+	if u.TeacherID == 0 {
+		return QuoteT{}, InvalidTeacherIDError{"ConfirmUnverifiedQuote: unverifiedQuote has no TeacherID"}
+	}
+
+	s.lastQuoteID++
+	q := QuoteT{
+		QuoteID:   s.lastQuoteID,
+		TeacherID: u.TeacherID,
+		Context:   u.Context,
+		Text:      u.Text,
+		Unixtime:  u.Unixtime,
+	}
+	s.quotes = append(s.quotes, q)
+	s.submitters[q.QuoteID] = u.UserID
+	s.unverifiedQuotes = append(s.unverifiedQuotes[:i], s.unverifiedQuotes[i+1:]...)
+
+	return q, nil
+}
+
+// rejectUnverifiedQuote moves an unverified quote to the rejected quotes,
+// it must only be called while s.mutex is locked
+func (s *memoryStore) rejectUnverifiedQuote(ID int32, reason ReasonT, note string, now int64) error {
+	i := s.unverifiedQuoteIndex(ID)
+	if i < 0 {
+		return InvalidQuoteIDError{"RejectUnverifiedQuote: no matching database row found"}
+	}
+
+	s.rejectedQuotes = append(s.rejectedQuotes, RejectedQuoteT{s.unverifiedQuotes[i], reason, note, now})
+	s.unverifiedQuotes = append(s.unverifiedQuotes[:i], s.unverifiedQuotes[i+1:]...)
+	return nil
+}
+
+// assignTeacherToUnverifiedQuote sets the TeacherID of an unverified quote and clears its TeacherName,
+// it must only be called while s.mutex is locked
+func (s *memoryStore) assignTeacherToUnverifiedQuote(ID int32, teacherID int32) error {
+	if s.teacherIndex(teacherID) < 0 {
+		return InvalidTeacherIDError{"ModerateUnverifiedQuotes: no teacher with given TeacherID"}
+	}
+
+	i := s.unverifiedQuoteIndex(ID)
+	if i < 0 {
+		return InvalidQuoteIDError{"ModerateUnverifiedQuotes: no matching database row found"}
+	}
+
+	s.unverifiedQuotes[i].TeacherID = teacherID
+	s.unverifiedQuotes[i].TeacherName = ""
+	return nil
+}
+
+// deleteQuote deletes the quote with the given ID and its votes,
+// it must only be called while s.mutex is locked
+func (s *memoryStore) deleteQuote(ID int32) {
+	i := s.quoteIndex(ID)
+	if i < 0 {
+		return
+	}
+
+	s.quotes = append(s.quotes[:i], s.quotes[i+1:]...)
+	delete(s.submitters, ID)
+
+	for hash, vote := range s.votes {
+		if vote.QuoteID == ID {
+			delete(s.votes, hash)
+		}
+	}
+}
+
+// seed fills the store with some teachers, quotes and the three users
+// admin (password admin), user (password user) and moderator (password moderator).
+// The passwords are stored in plaintext and hashed by Initialize like legacy rows.
+func (s *memoryStore) seed() error {
+	for _, u := range []UserT{
+		{Name: "admin", Password: "admin", Role: RoleAdmin},
+		{Name: "user", Password: "user", Role: RoleUser},
+		{Name: "moderator", Password: "moderator", Role: RoleModerator},
+	} {
+		_, err := s.CreateUser(u)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, t := range []TeacherT{
+		{Name: "Heimburg", Title: "Herr", Note: "Sp Ge"},
+		{Name: "Spreer", Title: "Frau", Note: "Sp Eth"},
+		{Name: "Eidner", Title: "Frau", Note: "Sp Eth"},
+		{Name: "Krug", Title: "Herr", Note: "Ma Ph"},
+	} {
+		_, err := s.CreateTeacher(t)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, q := range []QuoteT{
+		{TeacherID: 1, Context: "Nicer Tag", Text: "AAA BBB CCC", Unixtime: 1600000000},
+		{TeacherID: 2, Context: "nutzer Tag", Text: "BBB CCC", Unixtime: 1600000100},
+		{TeacherID: 2, Context: "cooler Tag", Text: "DDD EEE", Unixtime: 1600000200},
+		{TeacherID: 4, Context: "asdfasdf", Text: "FFF DDD EEE", Unixtime: 1600000300},
+	} {
+		_, err := s.CreateQuote(q)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := s.CreateUnverifiedQuote(UnverifiedQuoteT{
+		UserID:      2,
+		TeacherName: "Frau Mustermann (En De)",
+		Context:     "Vertretungsstunde",
+		Text:        "GGG HHH",
+		Unixtime:    1600000400,
+	})
+	return err
+}
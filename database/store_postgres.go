@@ -0,0 +1,41 @@
+package database
+
+import (
+	"os"
+
+	"github.com/lib/pq"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                          GLOBAL PACKAGE VARIABLES                          */
+/* -------------------------------------------------------------------------- */
+
+// postgresDialect is the sqlDialect for PostgreSQL using lib/pq
+var postgresDialect = sqlDialect{
+	name:       "postgres",
+	migrations: "postgres",
+	// transaction level advisory lock, the key is arbitrary but must never change
+	lockMigrations: "SELECT pg_advisory_xact_lock(7262020)",
+	isForeignKeyViolation: func(err error) bool {
+		pqErr, ok := err.(*pq.Error)
+		return ok && pqErr.Code == "23503" // foreign_key_violation
+	},
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              GENERAL FUNCTIONS                             */
+/* -------------------------------------------------------------------------- */
+
+// openPostgresStore opens the PostgreSQL database configured by the
+// DB_HOST, DB_PORT, DB_USER, DB_PWD, DB_NAME and DB_SSLMODE environment variables
+func openPostgresStore() (Store, error) {
+	param := os.ExpandEnv(
+		`host=${DB_HOST}
+		 port=${DB_PORT}
+		 user=${DB_USER}
+		 password=${DB_PWD}
+		 dbname=${DB_NAME}
+		 sslmode=${DB_SSLMODE}`)
+
+	return openSQLStore(postgresDialect, param)
+}
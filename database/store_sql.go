@@ -0,0 +1,1154 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// sqlDialect contains everything that differs between the SQL based stores
+// name                   the name of the database/sql driver
+// migrations             the directory of the dialect's migrations, see migrate.go
+// lockMigrations         statement which locks the migrations until the end of the
+//
+//	transaction; empty if beginning a transaction already does that
+//
+// isForeignKeyViolation  reports whether err was caused by a violated foreign key constraint
+type sqlDialect struct {
+	name                  string
+	migrations            string
+	lockMigrations        string
+	isForeignKeyViolation func(err error) bool
+}
+
+// sqlStore implements Store for databases reachable through database/sql.
+// The queries are written to be understood by every sqlDialect.
+// SQLite binds $N placeholders in order of their first appearance,
+// so they have to be numbered in that order.
+type sqlStore struct {
+	db      *sql.DB
+	dialect sqlDialect
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              GENERAL FUNCTIONS                             */
+/* -------------------------------------------------------------------------- */
+
+// openSQLStore opens the database described by dataSource using the given dialect
+func openSQLStore(dialect sqlDialect, dataSource string) (*sqlStore, error) {
+	db, err := sql.Open(dialect.name, dataSource)
+	if err != nil {
+		return nil, DBError{"openSQLStore: opening " + dialect.name + " database failed", err}
+	}
+	return &sqlStore{db, dialect}, nil
+}
+
+func (s *sqlStore) Ping() error {
+	return s.db.Ping()
+}
+
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+// ExecuteQuery runs a raw query, see ExecuteQuery in database.go
+func (s *sqlStore) ExecuteQuery(query string) error {
+	_, err := s.db.Exec(query)
+	if err != nil {
+		return DBError{"ExecuteQuery: Exec failed", err}
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              QUOTES FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+func (s *sqlStore) GetQuotes() ([]QuoteT, error) {
+	rows, err := s.db.Query(`SELECT
+		QuoteID,
+		TeacherID,
+		Context,
+		Text,
+		Unixtime FROM quotes`)
+	if err != nil {
+		return nil, DBError{"GetQuotes: loading quotes from database failed", err}
+	}
+	defer rows.Close()
+
+	var quotes []QuoteT
+	for rows.Next() {
+		var q QuoteT
+		err = rows.Scan(&q.QuoteID, &q.TeacherID, &q.Context, &q.Text, &q.Unixtime)
+		if err != nil {
+			return nil, DBError{"GetQuotes: parsing quotes failed", err}
+		}
+		quotes = append(quotes, q)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, DBError{"GetQuotes: reading quotes failed", err}
+	}
+
+	return quotes, nil
+}
+
+func (s *sqlStore) CreateQuote(q QuoteT) (int32, error) {
+	var id int32
+	err := s.db.QueryRow(
+		`INSERT INTO quotes (TeacherID, Context, Text, Unixtime) VALUES ($1, $2, $3, $4) RETURNING QuoteID`,
+		q.TeacherID, q.Context, q.Text, q.Unixtime).Scan(&id)
+	if err != nil {
+		if s.dialect.isForeignKeyViolation(err) {
+			return 0, InvalidTeacherIDError{"CreateQuote: no teacher with given TeacherID"}
+		}
+		return 0, DBError{"CreateQuote: inserting quote into database failed", err}
+	}
+	return id, nil
+}
+
+func (s *sqlStore) UpdateQuote(q QuoteT) error {
+	res, err := s.db.Exec(
+		`UPDATE quotes SET TeacherID=$1, Context=$2, Text=$3 WHERE QuoteID=$4`,
+		q.TeacherID, q.Context, q.Text, q.QuoteID)
+	if err != nil {
+		if s.dialect.isForeignKeyViolation(err) {
+			return InvalidTeacherIDError{"UpdateQuote: no teacher with given TeacherID"}
+		}
+		return DBError{"UpdateQuote: updating quote in database failed", err}
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return InvalidQuoteIDError{"UpdateQuote: no matching database row found"}
+	}
+	return nil
+}
+
+func (s *sqlStore) DeleteQuote(ID int32) error {
+	res, err := s.db.Exec(`DELETE FROM quotes WHERE QuoteID=$1`, ID)
+	if err != nil {
+		return DBError{"DeleteQuote: deleting quote from database failed", err}
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return InvalidQuoteIDError{"DeleteQuote: no matching database row found"}
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              TEACHERS FUNCTIONS                            */
+/* -------------------------------------------------------------------------- */
+
+func (s *sqlStore) GetTeachers() ([]TeacherT, error) {
+	rows, err := s.db.Query(`SELECT
+		TeacherID,
+		Name,
+		Title,
+		Note FROM teachers`)
+	if err != nil {
+		return nil, DBError{"GetTeachers: loading teachers from database failed", err}
+	}
+	defer rows.Close()
+
+	var teachers []TeacherT
+	for rows.Next() {
+		var t TeacherT
+		err = rows.Scan(&t.TeacherID, &t.Name, &t.Title, &t.Note)
+		if err != nil {
+			return nil, DBError{"GetTeachers: parsing teachers failed", err}
+		}
+		teachers = append(teachers, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, DBError{"GetTeachers: reading teachers failed", err}
+	}
+
+	return teachers, nil
+}
+
+func (s *sqlStore) CreateTeacher(t TeacherT) (int32, error) {
+	var id int32
+	err := s.db.QueryRow(
+		`INSERT INTO teachers (Name, Title, Note) VALUES ($1, $2, $3) RETURNING TeacherID`,
+		t.Name, t.Title, t.Note).Scan(&id)
+	if err != nil {
+		return 0, DBError{"CreateTeacher: inserting teacher into database failed", err}
+	}
+	return id, nil
+}
+
+func (s *sqlStore) UpdateTeacher(t TeacherT) error {
+	res, err := s.db.Exec(
+		`UPDATE teachers SET Name=$1, Title=$2, Note=$3 WHERE TeacherID=$4`,
+		t.Name, t.Title, t.Note, t.TeacherID)
+	if err != nil {
+		return DBError{"UpdateTeacher: updating teacher in database failed", err}
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return InvalidTeacherIDError{"UpdateTeacher: no matching database row found"}
+	}
+	return nil
+}
+
+func (s *sqlStore) DeleteTeacher(ID int32, cascade bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return DBError{"DeleteTeacher: beginning transaction failed", err}
+	}
+	defer tx.Rollback()
+
+	if !cascade {
+		var inUse bool
+		err = tx.QueryRow(
+			`SELECT EXISTS (SELECT 1 FROM quotes WHERE TeacherID=$1) OR EXISTS (SELECT 1 FROM unverifiedQuotes WHERE TeacherID=$1)`,
+			ID).Scan(&inUse)
+		if err != nil {
+			return DBError{"DeleteTeacher: checking for quotes of teacher failed", err}
+		}
+		if inUse {
+			return TeacherInUseError{"DeleteTeacher: teacher still has (unverified) quotes"}
+		}
+	}
+
+	res, err := tx.Exec(`DELETE FROM teachers WHERE TeacherID=$1`, ID)
+	if err != nil {
+		return DBError{"DeleteTeacher: deleting teacher from database failed", err}
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return InvalidTeacherIDError{"DeleteTeacher: no matching database row found"}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return DBError{"DeleteTeacher: committing transaction failed", err}
+	}
+	return nil
+}
+
+func (s *sqlStore) MergeTeachers(ID int32, otherID int32) error {
+	// otherwise the teacher would be deleted together with its quotes
+	if ID == otherID {
+		return InvalidTeacherIDError{"MergeTeachers: cannot merge a teacher with itself"}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return DBError{"MergeTeachers: beginning transaction failed", err}
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM teachers WHERE TeacherID=$1)`, ID).Scan(&exists)
+	if err != nil {
+		return DBError{"MergeTeachers: checking for teacher failed", err}
+	}
+	if !exists {
+		return InvalidTeacherIDError{"MergeTeachers: no teacher with given TeacherID"}
+	}
+
+	_, err = tx.Exec(`UPDATE quotes SET TeacherID=$1 WHERE TeacherID=$2`, ID, otherID)
+	if err != nil {
+		if s.dialect.isForeignKeyViolation(err) {
+			return InvalidTeacherIDError{"MergeTeachers: no teacher with given TeacherID"}
+		}
+		return DBError{"MergeTeachers: reassigning quotes failed", err}
+	}
+
+	_, err = tx.Exec(`UPDATE unverifiedQuotes SET TeacherID=$1 WHERE TeacherID=$2`, ID, otherID)
+	if err != nil {
+		if s.dialect.isForeignKeyViolation(err) {
+			return InvalidTeacherIDError{"MergeTeachers: no teacher with given TeacherID"}
+		}
+		return DBError{"MergeTeachers: reassigning unverified quotes failed", err}
+	}
+
+	_, err = tx.Exec(`UPDATE rejectedQuotes SET TeacherID=$1 WHERE TeacherID=$2`, ID, otherID)
+	if err != nil {
+		return DBError{"MergeTeachers: reassigning rejected quotes failed", err}
+	}
+
+	res, err := tx.Exec(`DELETE FROM teachers WHERE TeacherID=$1`, otherID)
+	if err != nil {
+		return DBError{"MergeTeachers: deleting merged teacher from database failed", err}
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return InvalidTeacherIDError{"MergeTeachers: no teacher with given other TeacherID"}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return DBError{"MergeTeachers: committing transaction failed", err}
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          UNVERIFIED QUOTES FUNCTIONS                       */
+/* -------------------------------------------------------------------------- */
+
+func (s *sqlStore) GetUnverifiedQuotes() ([]UnverifiedQuoteT, error) {
+	rows, err := s.db.Query(`SELECT
+		UserID,
+		QuoteID,
+		TeacherID,
+		TeacherName,
+		Context,
+		Text,
+		Unixtime FROM unverifiedQuotes`)
+	if err != nil {
+		return nil, DBError{"GetUnverifiedQuotes: loading unverifiedQuotes from database failed", err}
+	}
+	defer rows.Close()
+
+	var quotes []UnverifiedQuoteT
+	for rows.Next() {
+		var q UnverifiedQuoteT
+		var TeacherID sql.NullInt32
+
+		err := rows.Scan(&q.UserID, &q.QuoteID, &TeacherID, &q.TeacherName, &q.Context, &q.Text, &q.Unixtime)
+		if err != nil {
+			return nil, DBError{"GetUnverifiedQuotes: parsing unverifiedQuotes failed", err}
+		}
+
+		// TeacherID can be null, see CreateUnverifiedQuote and UpdateUnverifiedQuote
+		if TeacherID.Valid {
+			q.TeacherID = TeacherID.Int32
+		}
+
+		quotes = append(quotes, q)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, DBError{"GetUnverifiedQuotes: reading unverifiedQuotes failed", err}
+	}
+
+	return quotes, nil
+}
+
+func (s *sqlStore) GetUnverifiedQuoteByID(ID int32) (UnverifiedQuoteT, error) {
+	var q UnverifiedQuoteT
+	var TeacherID sql.NullInt32
+
+	err := s.db.QueryRow(`SELECT
+		UserID,
+		TeacherID,
+		TeacherName,
+		Context,
+		Text,
+		Unixtime FROM unverifiedQuotes WHERE QuoteID=$1`, ID).Scan(
+		&q.UserID, &TeacherID, &q.TeacherName, &q.Context, &q.Text, &q.Unixtime)
+	if err == sql.ErrNoRows {
+		return UnverifiedQuoteT{}, InvalidQuoteIDError{"GetUnverifiedQuoteByID: no matching database row found"}
+	}
+	if err != nil {
+		return UnverifiedQuoteT{}, DBError{"GetUnverifiedQuoteByID: loading unverifiedQuote from database failed", err}
+	}
+
+	q.QuoteID = ID
+
+	// TeacherID can be null, see CreateUnverifiedQuote and UpdateUnverifiedQuote
+	if TeacherID.Valid {
+		q.TeacherID = TeacherID.Int32
+	}
+
+	return q, nil
+}
+
+func (s *sqlStore) CreateUnverifiedQuote(q UnverifiedQuoteT) (int32, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, DBError{"CreateUnverifiedQuote: beginning transaction failed", err}
+	}
+	defer tx.Rollback()
+
+	// the violated foreign key can't be told apart, so the user is checked first
+	var userExists bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE UserID=$1)`, q.UserID).Scan(&userExists)
+	if err != nil {
+		return 0, DBError{"CreateUnverifiedQuote: checking for user failed", err}
+	}
+	if !userExists {
+		return 0, InvalidUserIDError{"CreateUnverifiedQuote: no user with given UserID"}
+	}
+
+	var id int32
+	err = tx.QueryRow(
+		`INSERT INTO unverifiedQuotes (UserID, TeacherID, TeacherName, Context, Text, Unixtime) VALUES ($1, $2, $3, $4, $5, $6) RETURNING QuoteID`,
+		q.UserID, nullTeacherID(q.TeacherID), q.TeacherName, q.Context, q.Text, q.Unixtime).Scan(&id)
+	if err != nil {
+		if s.dialect.isForeignKeyViolation(err) {
+			return 0, InvalidTeacherIDError{"CreateUnverifiedQuote: no teacher with given TeacherID"}
+		}
+		return 0, DBError{"CreateUnverifiedQuote: inserting quote into database failed", err}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, DBError{"CreateUnverifiedQuote: committing transaction failed", err}
+	}
+	return id, nil
+}
+
+func (s *sqlStore) UpdateUnverifiedQuote(q UnverifiedQuoteT) error {
+	res, err := s.db.Exec(
+		`UPDATE unverifiedQuotes SET TeacherID=$1, TeacherName=$2, Context=$3, Text=$4 WHERE QuoteID=$5`,
+		nullTeacherID(q.TeacherID), q.TeacherName, q.Context, q.Text, q.QuoteID)
+	if err != nil {
+		if s.dialect.isForeignKeyViolation(err) {
+			return InvalidTeacherIDError{"UpdateUnverifiedQuote: no teacher with given TeacherID"}
+		}
+		return DBError{"UpdateUnverifiedQuote: updating unverifiedQuote in database failed", err}
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return InvalidQuoteIDError{"UpdateUnverifiedQuote: no matching database row found"}
+	}
+	return nil
+}
+
+func (s *sqlStore) DeleteUnverifiedQuote(ID int32) error {
+	res, err := s.db.Exec(`DELETE FROM unverifiedQuotes WHERE QuoteID=$1`, ID)
+	if err != nil {
+		return DBError{"DeleteUnverifiedQuote: deleting unverifiedQuote from database failed", err}
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return InvalidQuoteIDError{"DeleteUnverifiedQuote: no matching database row found"}
+	}
+	return nil
+}
+
+func (s *sqlStore) ConfirmUnverifiedQuote(ID int32) (QuoteT, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return QuoteT{}, DBError{"ConfirmUnverifiedQuote: beginning transaction failed", err}
+	}
+	defer tx.Rollback()
+
+	q, err := s.confirmUnverifiedQuote(tx, ID)
+	if err != nil {
+		return QuoteT{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return QuoteT{}, DBError{"ConfirmUnverifiedQuote: committing transaction failed", err}
+	}
+
+	return q, nil
+}
+
+func (s *sqlStore) RejectUnverifiedQuote(ID int32, reason ReasonT, note string, now int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return DBError{"RejectUnverifiedQuote: beginning transaction failed", err}
+	}
+	defer tx.Rollback()
+
+	err = s.rejectUnverifiedQuote(tx, ID, reason, note, now)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return DBError{"RejectUnverifiedQuote: committing transaction failed", err}
+	}
+	return nil
+}
+
+func (s *sqlStore) ModerateUnverifiedQuotes(IDs []int32, m ModerationT, now int64) ([]ModerationResultT, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, DBError{"ModerateUnverifiedQuotes: beginning transaction failed", err}
+	}
+	defer tx.Rollback()
+
+	// an unknown TeacherID would violate the foreign key, which aborts the whole transaction in PostgreSQL
+	teacherExists := true
+	if m.Action == ActionAssignTeacher {
+		err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM teachers WHERE TeacherID=$1)`, m.TeacherID).Scan(&teacherExists)
+		if err != nil {
+			return nil, DBError{"ModerateUnverifiedQuotes: checking for teacher failed", err}
+		}
+	}
+
+	results := make([]ModerationResultT, len(IDs))
+	failed := false
+	for i, ID := range IDs {
+		results[i].QuoteID = ID
+
+		switch m.Action {
+		case ActionConfirm:
+			results[i].Quote, err = s.confirmUnverifiedQuote(tx, ID)
+		case ActionReject:
+			err = s.rejectUnverifiedQuote(tx, ID, m.Reason, m.Note, now)
+		case ActionAssignTeacher:
+			if !teacherExists {
+				err = InvalidTeacherIDError{"ModerateUnverifiedQuotes: no teacher with given TeacherID"}
+			} else {
+				err = s.assignTeacherToUnverifiedQuote(tx, ID, m.TeacherID)
+			}
+		default:
+			return nil, fmt.Errorf("ModerateUnverifiedQuotes: invalid Action %q", m.Action)
+		}
+
+		switch err.(type) {
+		case nil:
+		case InvalidQuoteIDError, InvalidTeacherIDError:
+			results[i].Err = err
+			failed = true
+		default:
+			return nil, err
+		}
+	}
+
+	// nothing is changed unless the action succeeded for all unverified quotes
+	if failed {
+		return results, nil
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, DBError{"ModerateUnverifiedQuotes: committing transaction failed", err}
+	}
+	return results, nil
+}
+
+// confirmUnverifiedQuote moves an unverified quote to the quotes within the transaction tx
+func (s *sqlStore) confirmUnverifiedQuote(tx *sql.Tx, ID int32) (QuoteT, error) {
+	var q QuoteT
+	var UserID, TeacherID sql.NullInt32
+
+	err := tx.QueryRow(
+		`DELETE FROM unverifiedQuotes WHERE QuoteID=$1 RETURNING UserID, TeacherID, Context, Text, Unixtime`,
+		ID).Scan(&UserID, &TeacherID, &q.Context, &q.Text, &q.Unixtime)
+	if err == sql.ErrNoRows {
+		return QuoteT{}, InvalidQuoteIDError{"ConfirmUnverifiedQuote: no matching database row found"}
+	}
+	if err != nil {
+		return QuoteT{}, DBError{"ConfirmUnverifiedQuote: deleting unverifiedQuote from database failed", err}
+	}
+
+	if !TeacherID.Valid {
+		return QuoteT{}, InvalidTeacherIDError{"ConfirmUnverifiedQuote: unverifiedQuote has no TeacherID"}
+	}
+	q.TeacherID = TeacherID.Int32
+
+	err = tx.QueryRow(
+		`INSERT INTO quotes (TeacherID, Context, Text, Unixtime, UserID) VALUES ($1, $2, $3, $4, $5) RETURNING QuoteID`,
+		q.TeacherID, q.Context, q.Text, q.Unixtime, UserID).Scan(&q.QuoteID)
+	if err != nil {
+		if s.dialect.isForeignKeyViolation(err) {
+			return QuoteT{}, InvalidTeacherIDError{"ConfirmUnverifiedQuote: no teacher with given TeacherID"}
+		}
+		return QuoteT{}, DBError{"ConfirmUnverifiedQuote: inserting quote into database failed", err}
+	}
+
+	return q, nil
+}
+
+// rejectUnverifiedQuote moves an unverified quote to the rejected quotes within the transaction tx
+func (s *sqlStore) rejectUnverifiedQuote(tx *sql.Tx, ID int32, reason ReasonT, note string, now int64) error {
+	var q UnverifiedQuoteT
+	var TeacherID sql.NullInt32
+
+	err := tx.QueryRow(
+		`DELETE FROM unverifiedQuotes WHERE QuoteID=$1 RETURNING UserID, TeacherID, TeacherName, Context, Text, Unixtime`,
+		ID).Scan(&q.UserID, &TeacherID, &q.TeacherName, &q.Context, &q.Text, &q.Unixtime)
+	if err == sql.ErrNoRows {
+		return InvalidQuoteIDError{"RejectUnverifiedQuote: no matching database row found"}
+	}
+	if err != nil {
+		return DBError{"RejectUnverifiedQuote: deleting unverifiedQuote from database failed", err}
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO rejectedQuotes (UserID, QuoteID, TeacherID, TeacherName, Context, Text, Unixtime, Reason, Note, Rejected)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		q.UserID, ID, TeacherID, q.TeacherName, q.Context, q.Text, q.Unixtime, reason, note, now)
+	if err != nil {
+		return DBError{"RejectUnverifiedQuote: inserting rejectedQuote into database failed", err}
+	}
+	return nil
+}
+
+// assignTeacherToUnverifiedQuote sets the TeacherID of an unverified quote within the transaction tx,
+// the TeacherName is cleared
+func (s *sqlStore) assignTeacherToUnverifiedQuote(tx *sql.Tx, ID int32, teacherID int32) error {
+	res, err := tx.Exec(`UPDATE unverifiedQuotes SET TeacherID=$1, TeacherName='' WHERE QuoteID=$2`, teacherID, ID)
+	if err != nil {
+		if s.dialect.isForeignKeyViolation(err) {
+			return InvalidTeacherIDError{"ModerateUnverifiedQuotes: no teacher with given TeacherID"}
+		}
+		return DBError{"ModerateUnverifiedQuotes: updating unverifiedQuote in database failed", err}
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return InvalidQuoteIDError{"ModerateUnverifiedQuotes: no matching database row found"}
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                             SUBMISSIONS FUNCTIONS                          */
+/* -------------------------------------------------------------------------- */
+
+func (s *sqlStore) GetSubmissions(userID int32) ([]SubmissionT, error) {
+	// the columns missing in a table are filled in, so that all rows can be scanned alike
+	rows, err := s.db.Query(`
+		SELECT 'pending', QuoteID, TeacherID, TeacherName, Context, Text, Unixtime, '', ''
+			FROM unverifiedQuotes WHERE UserID=$1
+		UNION ALL
+		SELECT 'rejected', QuoteID, TeacherID, TeacherName, Context, Text, Unixtime, Reason, Note
+			FROM rejectedQuotes WHERE UserID=$1
+		UNION ALL
+		SELECT 'confirmed', QuoteID, TeacherID, '', Context, Text, Unixtime, '', ''
+			FROM quotes WHERE UserID=$1`, userID)
+	if err != nil {
+		return nil, DBError{"GetSubmissions: loading submissions from database failed", err}
+	}
+	defer rows.Close()
+
+	var submissions []SubmissionT
+	for rows.Next() {
+		var sub SubmissionT
+		var TeacherID sql.NullInt32
+
+		err := rows.Scan(&sub.State, &sub.QuoteID, &TeacherID, &sub.TeacherName,
+			&sub.Context, &sub.Text, &sub.Unixtime, &sub.Reason, &sub.Note)
+		if err != nil {
+			return nil, DBError{"GetSubmissions: parsing submissions failed", err}
+		}
+
+		// TeacherID can be null, see CreateUnverifiedQuote and the rejectedQuotes table
+		if TeacherID.Valid {
+			sub.TeacherID = TeacherID.Int32
+		}
+		sub.UserID = userID
+
+		submissions = append(submissions, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, DBError{"GetSubmissions: reading submissions failed", err}
+	}
+
+	return submissions, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               USERS FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+func (s *sqlStore) GetUsers() ([]UserT, error) {
+	rows, err := s.db.Query(`SELECT
+		UserID,
+		Name,
+		Password,
+		Role,
+		Disabled FROM users`)
+	if err != nil {
+		return nil, DBError{"GetUsers: loading users from database failed", err}
+	}
+	defer rows.Close()
+
+	var users []UserT
+	for rows.Next() {
+		var u UserT
+		err = rows.Scan(&u.UserID, &u.Name, &u.Password, &u.Role, &u.Disabled)
+		if err != nil {
+			return nil, DBError{"GetUsers: parsing users failed", err}
+		}
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, DBError{"GetUsers: reading users failed", err}
+	}
+
+	return users, nil
+}
+
+func (s *sqlStore) GetUsernameByID(ID int32) (string, error) {
+	var username string
+	err := s.db.QueryRow(`SELECT Name FROM users WHERE UserID=$1`, ID).Scan(&username)
+	if err == sql.ErrNoRows {
+		return "", InvalidUserIDError{"GetUsernameByID: no matching user found"}
+	}
+	if err != nil {
+		return "", DBError{"GetUsernameByID: loading user from database failed", err}
+	}
+	return username, nil
+}
+
+func (s *sqlStore) CreateUser(u UserT) (int32, error) {
+	var id int32
+	err := s.db.QueryRow(
+		`INSERT INTO users (Name, Password, Role, Disabled) VALUES ($1, $2, $3, $4) RETURNING UserID`,
+		u.Name, u.Password, u.Role, u.Disabled).Scan(&id)
+	if err != nil {
+		return 0, DBError{"CreateUser: inserting user into database failed", err}
+	}
+	return id, nil
+}
+
+func (s *sqlStore) UpdateUserPassword(ID int32, password string) error {
+	res, err := s.db.Exec(`UPDATE users SET Password=$1 WHERE UserID=$2`, password, ID)
+	if err != nil {
+		return DBError{"UpdateUserPassword: updating user in database failed", err}
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return InvalidUserIDError{"UpdateUserPassword: no matching database row found"}
+	}
+	return nil
+}
+
+func (s *sqlStore) UpdateUser(u UserT, endSessions bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return DBError{"UpdateUser: beginning transaction failed", err}
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE users SET Name=$1, Role=$2, Disabled=$3 WHERE UserID=$4`,
+		u.Name, u.Role, u.Disabled, u.UserID)
+	if err != nil {
+		return DBError{"UpdateUser: updating user in database failed", err}
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return InvalidUserIDError{"UpdateUser: no matching database row found"}
+	}
+
+	if u.Password != "" {
+		_, err = tx.Exec(`UPDATE users SET Password=$1 WHERE UserID=$2`, u.Password, u.UserID)
+		if err != nil {
+			return DBError{"UpdateUser: updating password in database failed", err}
+		}
+
+		_, err = tx.Exec(`DELETE FROM tokens WHERE UserID=$1`, u.UserID)
+		if err != nil {
+			return DBError{"UpdateUser: deleting tokens from database failed", err}
+		}
+	}
+
+	if endSessions {
+		_, err = tx.Exec(`DELETE FROM sessions WHERE UserID=$1`, u.UserID)
+		if err != nil {
+			return DBError{"UpdateUser: deleting sessions from database failed", err}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return DBError{"UpdateUser: committing transaction failed", err}
+	}
+	return nil
+}
+
+// DeleteUser relies on ON DELETE CASCADE to delete the user's unverified and rejected quotes, votes and sessions
+func (s *sqlStore) DeleteUser(ID int32) error {
+	res, err := s.db.Exec(`DELETE FROM users WHERE UserID=$1`, ID)
+	if err != nil {
+		return DBError{"DeleteUser: deleting user from database failed", err}
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return InvalidUserIDError{"DeleteUser: no matching database row found"}
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              SESSIONS FUNCTIONS                            */
+/* -------------------------------------------------------------------------- */
+
+func (s *sqlStore) CreateSession(tokenHash string, session SessionT) error {
+	_, err := s.db.Exec(
+		`INSERT INTO sessions (TokenHash, UserID, Expires) VALUES ($1, $2, $3)`,
+		tokenHash, session.UserID, session.Expires)
+	if err != nil {
+		if s.dialect.isForeignKeyViolation(err) {
+			return InvalidUserIDError{"CreateSession: no user with given UserID"}
+		}
+		return DBError{"CreateSession: inserting session into database failed", err}
+	}
+	return nil
+}
+
+func (s *sqlStore) GetSession(tokenHash string) (SessionT, error) {
+	var session SessionT
+	err := s.db.QueryRow(`SELECT UserID, Expires FROM sessions WHERE TokenHash=$1`,
+		tokenHash).Scan(&session.UserID, &session.Expires)
+	if err == sql.ErrNoRows {
+		return SessionT{}, InvalidTokenError{"GetSession: no matching session found"}
+	}
+	if err != nil {
+		return SessionT{}, DBError{"GetSession: loading session from database failed", err}
+	}
+	return session, nil
+}
+
+func (s *sqlStore) DeleteSession(tokenHash string) error {
+	res, err := s.db.Exec(`DELETE FROM sessions WHERE TokenHash=$1`, tokenHash)
+	if err != nil {
+		return DBError{"DeleteSession: deleting session from database failed", err}
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return InvalidTokenError{"DeleteSession: no matching session found"}
+	}
+	return nil
+}
+
+func (s *sqlStore) DeleteExpiredSessions(now int64) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE Expires<$1`, now)
+	if err != nil {
+		return DBError{"DeleteExpiredSessions: deleting sessions from database failed", err}
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              INVITES FUNCTIONS                             */
+/* -------------------------------------------------------------------------- */
+
+func (s *sqlStore) GetInvites() ([]InviteT, error) {
+	rows, err := s.db.Query(`SELECT
+		InviteID,
+		MaxUses,
+		Uses,
+		Expires,
+		Role FROM invites`)
+	if err != nil {
+		return nil, DBError{"GetInvites: loading invites from database failed", err}
+	}
+	defer rows.Close()
+
+	var invites []InviteT
+	for rows.Next() {
+		var inv InviteT
+		err = rows.Scan(&inv.InviteID, &inv.MaxUses, &inv.Uses, &inv.Expires, &inv.Role)
+		if err != nil {
+			return nil, DBError{"GetInvites: parsing invites failed", err}
+		}
+		invites = append(invites, inv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, DBError{"GetInvites: reading invites failed", err}
+	}
+
+	return invites, nil
+}
+
+func (s *sqlStore) CreateInvite(codeHash string, inv InviteT) (int32, error) {
+	var id int32
+	err := s.db.QueryRow(
+		`INSERT INTO invites (CodeHash, MaxUses, Uses, Expires, Role) VALUES ($1, $2, 0, $3, $4) RETURNING InviteID`,
+		codeHash, inv.MaxUses, inv.Expires, inv.Role).Scan(&id)
+	if err != nil {
+		return 0, DBError{"CreateInvite: inserting invite into database failed", err}
+	}
+	return id, nil
+}
+
+func (s *sqlStore) DeleteInvite(ID int32) error {
+	res, err := s.db.Exec(`DELETE FROM invites WHERE InviteID=$1`, ID)
+	if err != nil {
+		return DBError{"DeleteInvite: deleting invite from database failed", err}
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return InvalidInviteIDError{"DeleteInvite: no matching database row found"}
+	}
+	return nil
+}
+
+// CreateUserWithInvite checks and counts the use of the invite in one UPDATE,
+// so two registrations can't both take the last use
+func (s *sqlStore) CreateUserWithInvite(codeHash string, u UserT, now int64) (UserT, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return UserT{}, DBError{"CreateUserWithInvite: beginning transaction failed", err}
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
+		`UPDATE invites SET Uses=Uses+1 WHERE CodeHash=$1 AND Uses<MaxUses AND Expires>=$2 RETURNING Role`,
+		codeHash, now).Scan(&u.Role)
+	if err == sql.ErrNoRows {
+		return UserT{}, InvalidTokenError{"CreateUserWithInvite: invite doesn't exist, expired or is used up"}
+	}
+	if err != nil {
+		return UserT{}, DBError{"CreateUserWithInvite: using invite failed", err}
+	}
+
+	err = tx.QueryRow(
+		`INSERT INTO users (Name, Password, Role, Disabled) VALUES ($1, $2, $3, $4) RETURNING UserID`,
+		u.Name, u.Password, u.Role, u.Disabled).Scan(&u.UserID)
+	if err != nil {
+		return UserT{}, DBError{"CreateUserWithInvite: inserting user into database failed", err}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return UserT{}, DBError{"CreateUserWithInvite: committing transaction failed", err}
+	}
+
+	return u, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          PASSWORD RESETS FUNCTIONS                         */
+/* -------------------------------------------------------------------------- */
+
+func (s *sqlStore) CreatePasswordReset(tokenHash string, reset PasswordResetT) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return DBError{"CreatePasswordReset: beginning transaction failed", err}
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM password_resets WHERE UserID=$1`, reset.UserID)
+	if err != nil {
+		return DBError{"CreatePasswordReset: deleting earlier password resets failed", err}
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO password_resets (TokenHash, UserID, Expires) VALUES ($1, $2, $3)`,
+		tokenHash, reset.UserID, reset.Expires)
+	if err != nil {
+		if s.dialect.isForeignKeyViolation(err) {
+			return InvalidUserIDError{"CreatePasswordReset: no user with given UserID"}
+		}
+		return DBError{"CreatePasswordReset: inserting password reset into database failed", err}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return DBError{"CreatePasswordReset: committing transaction failed", err}
+	}
+	return nil
+}
+
+// ResetUserPassword checks and deletes the password reset in one DELETE,
+// so a token can't be used twice
+func (s *sqlStore) ResetUserPassword(tokenHash string, password string, now int64) (int32, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, DBError{"ResetUserPassword: beginning transaction failed", err}
+	}
+	defer tx.Rollback()
+
+	var userID int32
+	err = tx.QueryRow(
+		`DELETE FROM password_resets WHERE TokenHash=$1 AND Expires>=$2 RETURNING UserID`,
+		tokenHash, now).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, InvalidTokenError{"ResetUserPassword: password reset doesn't exist or expired"}
+	}
+	if err != nil {
+		return 0, DBError{"ResetUserPassword: using password reset failed", err}
+	}
+
+	_, err = tx.Exec(`UPDATE users SET Password=$1 WHERE UserID=$2`, password, userID)
+	if err != nil {
+		return 0, DBError{"ResetUserPassword: updating user in database failed", err}
+	}
+
+	_, err = tx.Exec(`DELETE FROM sessions WHERE UserID=$1`, userID)
+	if err != nil {
+		return 0, DBError{"ResetUserPassword: deleting sessions from database failed", err}
+	}
+
+	_, err = tx.Exec(`DELETE FROM tokens WHERE UserID=$1`, userID)
+	if err != nil {
+		return 0, DBError{"ResetUserPassword: deleting tokens from database failed", err}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, DBError{"ResetUserPassword: committing transaction failed", err}
+	}
+
+	return userID, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              TOKENS FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+func (s *sqlStore) GetTokens(userID int32) ([]TokenT, error) {
+	rows, err := s.db.Query(`SELECT
+		TokenID,
+		UserID,
+		Name,
+		Scope,
+		Created,
+		Expires FROM tokens WHERE UserID=$1`, userID)
+	if err != nil {
+		return nil, DBError{"GetTokens: loading tokens from database failed", err}
+	}
+	defer rows.Close()
+
+	var tokens []TokenT
+	for rows.Next() {
+		var t TokenT
+		err = rows.Scan(&t.TokenID, &t.UserID, &t.Name, &t.Scope, &t.Created, &t.Expires)
+		if err != nil {
+			return nil, DBError{"GetTokens: parsing tokens failed", err}
+		}
+		tokens = append(tokens, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, DBError{"GetTokens: reading tokens failed", err}
+	}
+
+	return tokens, nil
+}
+
+func (s *sqlStore) GetToken(tokenHash string) (TokenT, error) {
+	var t TokenT
+	err := s.db.QueryRow(`SELECT TokenID, UserID, Name, Scope, Created, Expires FROM tokens WHERE TokenHash=$1`,
+		tokenHash).Scan(&t.TokenID, &t.UserID, &t.Name, &t.Scope, &t.Created, &t.Expires)
+	if err == sql.ErrNoRows {
+		return TokenT{}, InvalidTokenError{"GetToken: no matching token found"}
+	}
+	if err != nil {
+		return TokenT{}, DBError{"GetToken: loading token from database failed", err}
+	}
+	return t, nil
+}
+
+func (s *sqlStore) CreateToken(tokenHash string, t TokenT) (int32, error) {
+	var id int32
+	err := s.db.QueryRow(
+		`INSERT INTO tokens (TokenHash, UserID, Name, Scope, Created, Expires) VALUES ($1, $2, $3, $4, $5, $6) RETURNING TokenID`,
+		tokenHash, t.UserID, t.Name, t.Scope, t.Created, t.Expires).Scan(&id)
+	if err != nil {
+		if s.dialect.isForeignKeyViolation(err) {
+			return 0, InvalidUserIDError{"CreateToken: no user with given UserID"}
+		}
+		return 0, DBError{"CreateToken: inserting token into database failed", err}
+	}
+	return id, nil
+}
+
+func (s *sqlStore) DeleteToken(userID int32, ID int32) error {
+	res, err := s.db.Exec(`DELETE FROM tokens WHERE UserID=$1 AND TokenID=$2`, userID, ID)
+	if err != nil {
+		return DBError{"DeleteToken: deleting token from database failed", err}
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return InvalidTokenIDError{"DeleteToken: no matching database row found"}
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                            RATE LIMITS FUNCTIONS                           */
+/* -------------------------------------------------------------------------- */
+
+func (s *sqlStore) GetRateLimits() ([]RateLimitT, error) {
+	rows, err := s.db.Query(`SELECT
+		Name,
+		Burst,
+		PerHour FROM rate_limits`)
+	if err != nil {
+		return nil, DBError{"GetRateLimits: loading rate limits from database failed", err}
+	}
+	defer rows.Close()
+
+	var limits []RateLimitT
+	for rows.Next() {
+		var l RateLimitT
+		err = rows.Scan(&l.Name, &l.Burst, &l.PerHour)
+		if err != nil {
+			return nil, DBError{"GetRateLimits: parsing rate limits failed", err}
+		}
+		limits = append(limits, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, DBError{"GetRateLimits: reading rate limits failed", err}
+	}
+
+	return limits, nil
+}
+
+func (s *sqlStore) PutRateLimit(l RateLimitT) error {
+	_, err := s.db.Exec(
+		`INSERT INTO rate_limits (Name, Burst, PerHour) VALUES ($1, $2, $3)
+		 ON CONFLICT (Name) DO UPDATE SET
+			Burst=EXCLUDED.Burst, PerHour=EXCLUDED.PerHour`,
+		l.Name, l.Burst, l.PerHour)
+	if err != nil {
+		return DBError{"PutRateLimit: storing rate limit in database failed", err}
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               VOTES FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+func (s *sqlStore) GetVotes() ([]VoteT, error) {
+	rows, err := s.db.Query(`SELECT
+		UserID,
+		QuoteID,
+		Rating FROM votes`)
+	if err != nil {
+		return nil, DBError{"GetVotes: loading votes from database failed", err}
+	}
+	defer rows.Close()
+
+	var votes []VoteT
+	for rows.Next() {
+		var vote VoteT
+		err = rows.Scan(&vote.UserID, &vote.QuoteID, &vote.Val)
+		if err != nil {
+			return nil, DBError{"GetVotes: parsing votes failed", err}
+		}
+		votes = append(votes, vote)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, DBError{"GetVotes: reading votes failed", err}
+	}
+
+	return votes, nil
+}
+
+func (s *sqlStore) PutVote(vote VoteT) error {
+	_, err := s.db.Exec(
+		`INSERT INTO votes (Hash, UserID, QuoteID, Rating) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (Hash) DO UPDATE SET
+			UserID=EXCLUDED.UserID, QuoteID=EXCLUDED.QuoteID, Rating=EXCLUDED.Rating`,
+		voteHash(vote), vote.UserID, vote.QuoteID, vote.Val)
+	if err != nil {
+		if s.dialect.isForeignKeyViolation(err) {
+			// the UserID belongs to an authenticated user, so it must be the QuoteID
+			return InvalidQuoteIDError{"PutVote: QuoteID unknown"}
+		}
+		return DBError{"PutVote: inserting vote into database failed", err}
+	}
+	return nil
+}
+
+func (s *sqlStore) DeleteVote(userID int32, quoteID int32) error {
+	_, err := s.db.Exec(`DELETE FROM votes WHERE Hash = $1`,
+		voteHash(VoteT{UserID: userID, QuoteID: quoteID}))
+	if err != nil {
+		return DBError{"DeleteVote: deleting vote from database failed", err}
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              HELPER FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+// nullTeacherID stores TeacherID 0 as NULL, because 0 isn't a valid foreign key
+func nullTeacherID(ID int32) sql.NullInt32 {
+	return sql.NullInt32{Int32: ID, Valid: ID != 0}
+}
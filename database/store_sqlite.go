@@ -0,0 +1,48 @@
+package database
+
+import (
+	"os"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                  CONSTANTS                                 */
+/* -------------------------------------------------------------------------- */
+
+// sqliteDefaultPath is used if DB_PATH is not set
+const sqliteDefaultPath = "quote_gallery.db"
+
+/* -------------------------------------------------------------------------- */
+/*                          GLOBAL PACKAGE VARIABLES                          */
+/* -------------------------------------------------------------------------- */
+
+// sqliteDialect is the sqlDialect for SQLite using mattn/go-sqlite3
+// The migrations mirror those of postgresDialect.
+var sqliteDialect = sqlDialect{
+	name:       "sqlite3",
+	migrations: "sqlite",
+	// not needed, transactions are begun with BEGIN IMMEDIATE (see _txlock in openSQLiteStore)
+	lockMigrations: "",
+	isForeignKeyViolation: func(err error) bool {
+		sqliteErr, ok := err.(sqlite3.Error)
+		return ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
+	},
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              GENERAL FUNCTIONS                             */
+/* -------------------------------------------------------------------------- */
+
+// openSQLiteStore opens (or creates) the SQLite database file configured by the
+// DB_PATH environment variable.
+// Foreign keys are switched on for every connection, SQLite ignores them by default.
+// Transactions take the write lock immediately, so concurrent writers wait instead of failing.
+func openSQLiteStore() (Store, error) {
+	path := os.Getenv("DB_PATH")
+	if path == "" {
+		path = sqliteDefaultPath
+	}
+
+	return openSQLStore(sqliteDialect, "file:"+path+"?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate")
+}
@@ -0,0 +1,635 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                    TESTS                                   */
+/* -------------------------------------------------------------------------- */
+
+// TestStore runs every case of storeTests against an empty store of every DB_DRIVER
+// which needs no server, so that all of them are known to behave the same
+func TestStore(t *testing.T) {
+	for _, test := range storeTests {
+		for driver, open := range testStores {
+			t.Run(test.name+"/"+driver, func(t *testing.T) {
+				test.run(t, open(t))
+			})
+		}
+	}
+}
+
+var storeTests = []struct {
+	name string
+	run  func(t *testing.T, s Store)
+}{
+	{"CRUD", testStoreCRUD},
+	{"ForeignKeys", testStoreForeignKeys},
+	{"DeleteTeacher", testStoreDeleteTeacher},
+	{"MergeTeachers", testStoreMergeTeachers},
+	{"ModerateUnverifiedQuotes", testStoreModerateUnverifiedQuotes},
+	{"Votes", testStoreVotes},
+	{"Credentials", testStoreCredentials},
+}
+
+// testStoreCRUD creates, updates and deletes every kind of row whose UPDATE query
+// binds more than one placeholder, so misnumbered placeholders are noticed
+func testStoreCRUD(t *testing.T, s Store) {
+	userID := mustCreateUser(t, s, "user")
+
+	// teachers
+	teacherID, err := s.CreateTeacher(TeacherT{Name: "Name", Title: "Herr", Note: "Ma"})
+	if err != nil {
+		t.Fatalf("CreateTeacher: %v", err)
+	}
+
+	err = s.UpdateTeacher(TeacherT{TeacherID: teacherID, Name: "Other", Title: "Frau", Note: "De"})
+	if err != nil {
+		t.Fatalf("UpdateTeacher: %v", err)
+	}
+
+	teachers, err := s.GetTeachers()
+	if err != nil {
+		t.Fatalf("GetTeachers: %v", err)
+	}
+	if want := (TeacherT{teacherID, "Other", "Frau", "De"}); len(teachers) != 1 || teachers[0] != want {
+		t.Fatalf("GetTeachers = %v, want [%v]", teachers, want)
+	}
+
+	// quotes
+	quoteID, err := s.CreateQuote(QuoteT{TeacherID: teacherID, Context: "c", Text: "t", Unixtime: 1})
+	if err != nil {
+		t.Fatalf("CreateQuote: %v", err)
+	}
+
+	err = s.UpdateQuote(QuoteT{QuoteID: quoteID, TeacherID: teacherID, Context: "c2", Text: "t2"})
+	if err != nil {
+		t.Fatalf("UpdateQuote: %v", err)
+	}
+
+	quotes, err := s.GetQuotes()
+	if err != nil {
+		t.Fatalf("GetQuotes: %v", err)
+	}
+	if len(quotes) != 1 || quotes[0].QuoteID != quoteID || quotes[0].Context != "c2" || quotes[0].Text != "t2" || quotes[0].Unixtime != 1 {
+		t.Fatalf("GetQuotes = %v, want the updated quote #%d", quotes, quoteID)
+	}
+
+	// unverified quotes
+	unverifiedID, err := s.CreateUnverifiedQuote(UnverifiedQuoteT{UserID: userID, TeacherName: "new", Context: "c", Text: "t", Unixtime: 2})
+	if err != nil {
+		t.Fatalf("CreateUnverifiedQuote: %v", err)
+	}
+
+	err = s.UpdateUnverifiedQuote(UnverifiedQuoteT{QuoteID: unverifiedID, TeacherID: teacherID, Context: "c2", Text: "t2"})
+	if err != nil {
+		t.Fatalf("UpdateUnverifiedQuote: %v", err)
+	}
+
+	unverified, err := s.GetUnverifiedQuoteByID(unverifiedID)
+	if err != nil {
+		t.Fatalf("GetUnverifiedQuoteByID: %v", err)
+	}
+	if want := (UnverifiedQuoteT{userID, unverifiedID, teacherID, "", "c2", "t2", 2}); unverified != want {
+		t.Fatalf("GetUnverifiedQuoteByID = %v, want %v", unverified, want)
+	}
+
+	// users
+	err = s.UpdateUserPassword(userID, "otherhash")
+	if err != nil {
+		t.Fatalf("UpdateUserPassword: %v", err)
+	}
+
+	err = s.UpdateUser(UserT{UserID: userID, Name: "renamed", Role: RoleModerator, Disabled: true}, false)
+	if err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	users, err := s.GetUsers()
+	if err != nil {
+		t.Fatalf("GetUsers: %v", err)
+	}
+	if want := (UserT{userID, "renamed", "otherhash", RoleModerator, true}); len(users) != 1 || users[0] != want {
+		t.Fatalf("GetUsers = %v, want [%v]", users, want)
+	}
+
+	// deleting
+	err = s.DeleteUnverifiedQuote(unverifiedID)
+	if err != nil {
+		t.Fatalf("DeleteUnverifiedQuote: %v", err)
+	}
+	err = s.DeleteQuote(quoteID)
+	if err != nil {
+		t.Fatalf("DeleteQuote: %v", err)
+	}
+	err = s.DeleteTeacher(teacherID, false)
+	if err != nil {
+		t.Fatalf("DeleteTeacher: %v", err)
+	}
+	err = s.DeleteUser(userID)
+	if err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+
+	// deleting again fails, there is nothing left
+	if _, ok := s.DeleteQuote(quoteID).(InvalidQuoteIDError); !ok {
+		t.Errorf("DeleteQuote of a deleted quote didn't return InvalidQuoteIDError")
+	}
+	if _, ok := s.DeleteTeacher(teacherID, false).(InvalidTeacherIDError); !ok {
+		t.Errorf("DeleteTeacher of a deleted teacher didn't return InvalidTeacherIDError")
+	}
+	if _, ok := s.DeleteUnverifiedQuote(unverifiedID).(InvalidQuoteIDError); !ok {
+		t.Errorf("DeleteUnverifiedQuote of a deleted unverified quote didn't return InvalidQuoteIDError")
+	}
+	if _, ok := s.DeleteUser(userID).(InvalidUserIDError); !ok {
+		t.Errorf("DeleteUser of a deleted user didn't return InvalidUserIDError")
+	}
+}
+
+// testStoreForeignKeys references rows which don't exist
+func testStoreForeignKeys(t *testing.T, s Store) {
+	userID := mustCreateUser(t, s, "user")
+	teacherID := mustCreateTeacher(t, s, "Name")
+	quoteID := mustCreateQuote(t, s, teacherID)
+	unverifiedID := mustCreateUnverifiedQuote(t, s, userID, teacherID)
+
+	if _, err := s.CreateQuote(QuoteT{TeacherID: teacherID + 1, Text: "t"}); !isInvalidTeacherID(err) {
+		t.Errorf("CreateQuote with unknown TeacherID returned %v, want InvalidTeacherIDError", err)
+	}
+	if err := s.UpdateQuote(QuoteT{QuoteID: quoteID, TeacherID: teacherID + 1, Text: "t"}); !isInvalidTeacherID(err) {
+		t.Errorf("UpdateQuote with unknown TeacherID returned %v, want InvalidTeacherIDError", err)
+	}
+	if _, err := s.CreateUnverifiedQuote(UnverifiedQuoteT{UserID: userID, TeacherID: teacherID + 1, Text: "t"}); !isInvalidTeacherID(err) {
+		t.Errorf("CreateUnverifiedQuote with unknown TeacherID returned %v, want InvalidTeacherIDError", err)
+	}
+	if err := s.UpdateUnverifiedQuote(UnverifiedQuoteT{QuoteID: unverifiedID, TeacherID: teacherID + 1, Text: "t"}); !isInvalidTeacherID(err) {
+		t.Errorf("UpdateUnverifiedQuote with unknown TeacherID returned %v, want InvalidTeacherIDError", err)
+	}
+	if _, err := s.CreateUnverifiedQuote(UnverifiedQuoteT{UserID: userID + 1, TeacherID: teacherID, Text: "t"}); !isInvalidUserID(err) {
+		t.Errorf("CreateUnverifiedQuote with unknown UserID returned %v, want InvalidUserIDError", err)
+	}
+	if err := s.PutVote(VoteT{UserID: userID, QuoteID: quoteID + 1, Val: 3}); !isInvalidQuoteID(err) {
+		t.Errorf("PutVote with unknown QuoteID returned %v, want InvalidQuoteIDError", err)
+	}
+
+	// nothing was changed by the failed calls
+	quotes, err := s.GetQuotes()
+	if err != nil {
+		t.Fatalf("GetQuotes: %v", err)
+	}
+	if len(quotes) != 1 || quotes[0].TeacherID != teacherID {
+		t.Errorf("GetQuotes = %v, want only quote #%d of teacher #%d", quotes, quoteID, teacherID)
+	}
+	unverifiedQuotes, err := s.GetUnverifiedQuotes()
+	if err != nil {
+		t.Fatalf("GetUnverifiedQuotes: %v", err)
+	}
+	if len(unverifiedQuotes) != 1 || unverifiedQuotes[0].TeacherID != teacherID {
+		t.Errorf("GetUnverifiedQuotes = %v, want only unverified quote #%d of teacher #%d", unverifiedQuotes, unverifiedID, teacherID)
+	}
+
+	// deleting a quote deletes its votes, deleting a user their unverified quotes and votes
+	otherQuoteID := mustCreateQuote(t, s, teacherID)
+	mustPutVote(t, s, VoteT{UserID: userID, QuoteID: quoteID, Val: 3})
+	mustPutVote(t, s, VoteT{UserID: userID, QuoteID: otherQuoteID, Val: 4})
+
+	if err := s.DeleteQuote(quoteID); err != nil {
+		t.Fatalf("DeleteQuote: %v", err)
+	}
+	if votes := mustGetVotes(t, s); len(votes) != 1 || votes[0].QuoteID != otherQuoteID {
+		t.Errorf("GetVotes after DeleteQuote = %v, want only the vote for quote #%d", votes, otherQuoteID)
+	}
+
+	if err := s.DeleteUser(userID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if votes := mustGetVotes(t, s); len(votes) != 0 {
+		t.Errorf("GetVotes after DeleteUser = %v, want none", votes)
+	}
+	if _, err := s.GetUnverifiedQuoteByID(unverifiedID); !isInvalidQuoteID(err) {
+		t.Errorf("GetUnverifiedQuoteByID of a deleted user's quote returned %v, want InvalidQuoteIDError", err)
+	}
+}
+
+// testStoreDeleteTeacher deletes teachers which still have (unverified) quotes
+func testStoreDeleteTeacher(t *testing.T, s Store) {
+	userID := mustCreateUser(t, s, "user")
+	teacherID := mustCreateTeacher(t, s, "Quotes")
+	otherTeacherID := mustCreateTeacher(t, s, "Unverified")
+	keptTeacherID := mustCreateTeacher(t, s, "Kept")
+
+	quoteID := mustCreateQuote(t, s, teacherID)
+	keptQuoteID := mustCreateQuote(t, s, keptTeacherID)
+	mustCreateUnverifiedQuote(t, s, userID, otherTeacherID)
+	keptUnverifiedID := mustCreateUnverifiedQuote(t, s, userID, keptTeacherID)
+	mustPutVote(t, s, VoteT{UserID: userID, QuoteID: quoteID, Val: 5})
+
+	for _, ID := range []int32{teacherID, otherTeacherID} {
+		if err := s.DeleteTeacher(ID, false); !isTeacherInUse(err) {
+			t.Errorf("DeleteTeacher of teacher #%d in use returned %v, want TeacherInUseError", ID, err)
+		}
+	}
+	if teachers := mustGetTeachers(t, s); len(teachers) != 3 {
+		t.Fatalf("GetTeachers after failed DeleteTeacher = %v, want all three teachers", teachers)
+	}
+
+	for _, ID := range []int32{teacherID, otherTeacherID} {
+		if err := s.DeleteTeacher(ID, true); err != nil {
+			t.Fatalf("DeleteTeacher with cascade of teacher #%d: %v", ID, err)
+		}
+	}
+
+	if teachers := mustGetTeachers(t, s); len(teachers) != 1 || teachers[0].TeacherID != keptTeacherID {
+		t.Errorf("GetTeachers after DeleteTeacher = %v, want only teacher #%d", teachers, keptTeacherID)
+	}
+	quotes, err := s.GetQuotes()
+	if err != nil {
+		t.Fatalf("GetQuotes: %v", err)
+	}
+	if len(quotes) != 1 || quotes[0].QuoteID != keptQuoteID {
+		t.Errorf("GetQuotes after DeleteTeacher = %v, want only quote #%d", quotes, keptQuoteID)
+	}
+	unverifiedQuotes, err := s.GetUnverifiedQuotes()
+	if err != nil {
+		t.Fatalf("GetUnverifiedQuotes: %v", err)
+	}
+	if len(unverifiedQuotes) != 1 || unverifiedQuotes[0].QuoteID != keptUnverifiedID {
+		t.Errorf("GetUnverifiedQuotes after DeleteTeacher = %v, want only unverified quote #%d", unverifiedQuotes, keptUnverifiedID)
+	}
+	if votes := mustGetVotes(t, s); len(votes) != 0 {
+		t.Errorf("GetVotes after DeleteTeacher = %v, want none", votes)
+	}
+}
+
+// testStoreMergeTeachers merges a teacher with (unverified) quotes into another one
+func testStoreMergeTeachers(t *testing.T, s Store) {
+	userID := mustCreateUser(t, s, "user")
+	teacherID := mustCreateTeacher(t, s, "Kept")
+	otherTeacherID := mustCreateTeacher(t, s, "Merged")
+
+	mustCreateQuote(t, s, teacherID)
+	mustCreateQuote(t, s, otherTeacherID)
+	mustCreateUnverifiedQuote(t, s, userID, otherTeacherID)
+
+	if err := s.MergeTeachers(teacherID, teacherID); !isInvalidTeacherID(err) {
+		t.Errorf("MergeTeachers of a teacher with itself returned %v, want InvalidTeacherIDError", err)
+	}
+	if err := s.MergeTeachers(teacherID, otherTeacherID+1); !isInvalidTeacherID(err) {
+		t.Errorf("MergeTeachers with unknown other TeacherID returned %v, want InvalidTeacherIDError", err)
+	}
+	if err := s.MergeTeachers(otherTeacherID+1, teacherID); !isInvalidTeacherID(err) {
+		t.Errorf("MergeTeachers with unknown TeacherID returned %v, want InvalidTeacherIDError", err)
+	}
+	if teachers := mustGetTeachers(t, s); len(teachers) != 2 {
+		t.Fatalf("GetTeachers after failed MergeTeachers = %v, want both teachers", teachers)
+	}
+
+	if err := s.MergeTeachers(teacherID, otherTeacherID); err != nil {
+		t.Fatalf("MergeTeachers: %v", err)
+	}
+
+	if teachers := mustGetTeachers(t, s); len(teachers) != 1 || teachers[0].TeacherID != teacherID {
+		t.Errorf("GetTeachers after MergeTeachers = %v, want only teacher #%d", teachers, teacherID)
+	}
+	quotes, err := s.GetQuotes()
+	if err != nil {
+		t.Fatalf("GetQuotes: %v", err)
+	}
+	for _, q := range quotes {
+		if q.TeacherID != teacherID {
+			t.Errorf("quote #%d has TeacherID %d after MergeTeachers, want %d", q.QuoteID, q.TeacherID, teacherID)
+		}
+	}
+	unverifiedQuotes, err := s.GetUnverifiedQuotes()
+	if err != nil {
+		t.Fatalf("GetUnverifiedQuotes: %v", err)
+	}
+	for _, q := range unverifiedQuotes {
+		if q.TeacherID != teacherID {
+			t.Errorf("unverified quote #%d has TeacherID %d after MergeTeachers, want %d", q.QuoteID, q.TeacherID, teacherID)
+		}
+	}
+}
+
+// testStoreModerateUnverifiedQuotes checks that nothing is changed
+// unless the action succeeds for every unverified quote
+func testStoreModerateUnverifiedQuotes(t *testing.T, s Store) {
+	userID := mustCreateUser(t, s, "user")
+	teacherID := mustCreateTeacher(t, s, "Name")
+	withTeacherID := mustCreateUnverifiedQuote(t, s, userID, teacherID)
+	withoutTeacherID := mustCreateUnverifiedQuote(t, s, userID, 0)
+
+	tests := []struct {
+		name   string
+		IDs    []int32
+		m      ModerationT
+		failed int32 // QuoteID of the failing result
+	}{
+		{"confirm without teacher", []int32{withTeacherID, withoutTeacherID}, ModerationT{Action: ActionConfirm}, withoutTeacherID},
+		{"reject unknown", []int32{withTeacherID, withoutTeacherID + 1}, ModerationT{Action: ActionReject, Reason: ReasonDuplicate}, withoutTeacherID + 1},
+		{"assign unknown", []int32{withoutTeacherID + 1, withTeacherID}, ModerationT{Action: ActionAssignTeacher, TeacherID: teacherID}, withoutTeacherID + 1},
+	}
+
+	for _, test := range tests {
+		results, err := s.ModerateUnverifiedQuotes(test.IDs, test.m, 10)
+		if err != nil {
+			t.Fatalf("%s: ModerateUnverifiedQuotes: %v", test.name, err)
+		}
+		if len(results) != len(test.IDs) {
+			t.Fatalf("%s: ModerateUnverifiedQuotes returned %d results, want %d", test.name, len(results), len(test.IDs))
+		}
+		for i, result := range results {
+			if result.QuoteID != test.IDs[i] {
+				t.Errorf("%s: result %d has QuoteID %d, want %d", test.name, i, result.QuoteID, test.IDs[i])
+			}
+			if (result.QuoteID == test.failed) != (result.Err != nil) {
+				t.Errorf("%s: result for unverified quote #%d has error %v", test.name, result.QuoteID, result.Err)
+			}
+		}
+
+		if quotes, _ := s.GetQuotes(); len(quotes) != 0 {
+			t.Errorf("%s: GetQuotes after failed ModerateUnverifiedQuotes = %v, want none", test.name, quotes)
+		}
+		unverifiedQuotes, err := s.GetUnverifiedQuotes()
+		if err != nil {
+			t.Fatalf("GetUnverifiedQuotes: %v", err)
+		}
+		if len(unverifiedQuotes) != 2 {
+			t.Errorf("%s: GetUnverifiedQuotes after failed ModerateUnverifiedQuotes = %v, want both unverified quotes", test.name, unverifiedQuotes)
+		}
+		if submissions, _ := s.GetSubmissions(userID); len(submissions) != 2 {
+			t.Errorf("%s: GetSubmissions after failed ModerateUnverifiedQuotes = %v, want both unverified quotes", test.name, submissions)
+		}
+	}
+
+	// assigning the teacher lets both unverified quotes be confirmed
+	results, err := s.ModerateUnverifiedQuotes([]int32{withoutTeacherID}, ModerationT{Action: ActionAssignTeacher, TeacherID: teacherID}, 10)
+	if err != nil || results[0].Err != nil {
+		t.Fatalf("ModerateUnverifiedQuotes assigning teacher: %v, %v", err, results)
+	}
+	results, err = s.ModerateUnverifiedQuotes([]int32{withTeacherID, withoutTeacherID}, ModerationT{Action: ActionConfirm}, 10)
+	if err != nil {
+		t.Fatalf("ModerateUnverifiedQuotes confirming: %v", err)
+	}
+	for _, result := range results {
+		if result.Err != nil || result.Quote.QuoteID == 0 || result.Quote.TeacherID != teacherID {
+			t.Errorf("result for confirmed unverified quote #%d = %+v, want the new quote", result.QuoteID, result)
+		}
+	}
+	if quotes, _ := s.GetQuotes(); len(quotes) != 2 {
+		t.Errorf("GetQuotes after ModerateUnverifiedQuotes = %v, want both confirmed quotes", quotes)
+	}
+	if unverifiedQuotes, _ := s.GetUnverifiedQuotes(); len(unverifiedQuotes) != 0 {
+		t.Errorf("GetUnverifiedQuotes after ModerateUnverifiedQuotes = %v, want none", unverifiedQuotes)
+	}
+}
+
+// testStoreVotes votes and unvotes through the cache, which computes the Stats,
+// and reloads the cache to see that the stored votes add up to the same Stats
+func testStoreVotes(t *testing.T, s Store) {
+	userID := mustCreateUser(t, s, "user")
+	otherUserID := mustCreateUser(t, s, "other")
+	quoteID := mustCreateQuote(t, s, mustCreateTeacher(t, s, "Name"))
+
+	useStore(t, s)
+
+	steps := []struct {
+		name string
+		do   func() (QuoteT, error)
+		num  int32
+		data [5]int32
+	}{
+		{"vote", func() (QuoteT, error) { return AddVote(VoteT{userID, quoteID, 5}) }, 1, [5]int32{0, 0, 0, 0, 1}},
+		{"vote other", func() (QuoteT, error) { return AddVote(VoteT{otherUserID, quoteID, 1}) }, 2, [5]int32{1, 0, 0, 0, 1}},
+		{"revote", func() (QuoteT, error) { return AddVote(VoteT{userID, quoteID, 3}) }, 2, [5]int32{1, 0, 1, 0, 0}},
+		{"unvote", func() (QuoteT, error) { return DeleteVote(userID, quoteID) }, 1, [5]int32{1, 0, 0, 0, 0}},
+		{"unvote again", func() (QuoteT, error) { return DeleteVote(userID, quoteID) }, 1, [5]int32{1, 0, 0, 0, 0}},
+		{"reload", func() (QuoteT, error) {
+			if err := Initialize(); err != nil {
+				return QuoteT{}, err
+			}
+			return GetQuoteByID(quoteID)
+		}, 1, [5]int32{1, 0, 0, 0, 0}},
+	}
+
+	for _, step := range steps {
+		q, err := step.do()
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if q.Stats.Num != step.num || q.Stats.Data != step.data {
+			t.Errorf("%s: Stats.Num = %d, Stats.Data = %v, want %d, %v", step.name, q.Stats.Num, q.Stats.Data, step.num, step.data)
+		}
+	}
+
+	if _, err := AddVote(VoteT{userID, quoteID + 1, 3}); !isInvalidQuoteID(err) {
+		t.Errorf("AddVote for unknown QuoteID returned %v, want InvalidQuoteIDError", err)
+	}
+	if _, err := DeleteVote(userID, quoteID+1); !isInvalidQuoteID(err) {
+		t.Errorf("DeleteVote for unknown QuoteID returned %v, want InvalidQuoteIDError", err)
+	}
+}
+
+// testStoreCredentials checks which changes of a user end their sessions and delete their API tokens
+func testStoreCredentials(t *testing.T, s Store) {
+	userID := mustCreateUser(t, s, "user")
+	otherUserID := mustCreateUser(t, s, "other")
+	mustCreateCredentials(t, s, otherUserID, "other")
+
+	steps := []struct {
+		name     string
+		do       func() error
+		sessions bool // whether the session of the user is left
+		tokens   bool // whether the API token of the user is left
+	}{
+		{"rename", func() error {
+			return s.UpdateUser(UserT{UserID: userID, Name: "renamed", Role: RoleUser}, false)
+		}, true, true},
+		{"disable", func() error {
+			return s.UpdateUser(UserT{UserID: userID, Name: "renamed", Role: RoleUser, Disabled: true}, true)
+		}, false, true},
+		{"set password", func() error {
+			return s.UpdateUser(UserT{UserID: userID, Name: "renamed", Password: "otherhash", Role: RoleUser}, true)
+		}, false, false},
+		{"reset password", func() error {
+			err := s.CreatePasswordReset("reset", PasswordResetT{UserID: userID, Expires: 20})
+			if err != nil {
+				return err
+			}
+			_, err = s.ResetUserPassword("reset", "resethash", 10)
+			return err
+		}, false, false},
+	}
+
+	for _, step := range steps {
+		mustCreateCredentials(t, s, userID, step.name)
+
+		if err := step.do(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		if _, err := s.GetSession("session " + step.name); (err == nil) != step.sessions {
+			t.Errorf("%s: GetSession returned %v, want the session to be left: %v", step.name, err, step.sessions)
+		}
+		if tokens, _ := s.GetTokens(userID); (len(tokens) != 0) != step.tokens {
+			t.Errorf("%s: GetTokens = %v, want the API token to be left: %v", step.name, tokens, step.tokens)
+		}
+
+		// the other user is left alone
+		if _, err := s.GetSession("session other"); err != nil {
+			t.Errorf("%s: GetSession of another user: %v", step.name, err)
+		}
+		if tokens, _ := s.GetTokens(otherUserID); len(tokens) != 1 {
+			t.Errorf("%s: GetTokens of another user = %v, want one", step.name, tokens)
+		}
+
+		// start over for the next step
+		s.DeleteSession("session " + step.name)
+		tokens, _ := s.GetTokens(userID)
+		for _, token := range tokens {
+			s.DeleteToken(userID, token.TokenID)
+		}
+	}
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              HELPER FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+// testStores opens an empty, migrated store for every DB_DRIVER which needs no server
+var testStores = map[string]func(t *testing.T) Store{
+	"memory": openTestStore("memory"),
+	"sqlite": openTestStore("sqlite"),
+}
+
+// openTestStore returns a function opening an empty store with the given DB_DRIVER,
+// the store is closed once the test has finished
+func openTestStore(driver string) func(t *testing.T) Store {
+	return func(t *testing.T) Store {
+		t.Setenv("DB_DRIVER", driver)
+		t.Setenv("DB_PATH", filepath.Join(t.TempDir(), "test.db"))
+		t.Setenv("DB_SEED", "")
+
+		s, err := openStore()
+		if err != nil {
+			t.Fatalf("opening %s store failed: %v", driver, err)
+		}
+		t.Cleanup(func() { s.Close() })
+
+		err = s.Migrate()
+		if err != nil {
+			t.Fatalf("migrating %s store failed: %v", driver, err)
+		}
+		return s
+	}
+}
+
+// useStore makes s the store behind the exported functions and fills the cache from it,
+// the cache is cleared once the test has finished
+func useStore(t *testing.T, s Store) {
+	globalMutex.MajorLock()
+	store = s
+	globalMutex.MajorUnlock()
+
+	t.Cleanup(func() {
+		stopAutoCacheIndexing()
+
+		globalMutex.MajorLock()
+		defer globalMutex.MajorUnlock()
+		unsafeClearCache()
+		store = nil
+	})
+
+	if err := Initialize(); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+}
+
+func mustCreateUser(t *testing.T, s Store, name string) int32 {
+	ID, err := s.CreateUser(UserT{Name: name, Password: "hash", Role: RoleUser})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return ID
+}
+
+func mustCreateTeacher(t *testing.T, s Store, name string) int32 {
+	ID, err := s.CreateTeacher(TeacherT{Name: name})
+	if err != nil {
+		t.Fatalf("CreateTeacher: %v", err)
+	}
+	return ID
+}
+
+func mustCreateQuote(t *testing.T, s Store, teacherID int32) int32 {
+	ID, err := s.CreateQuote(QuoteT{TeacherID: teacherID, Text: "quote"})
+	if err != nil {
+		t.Fatalf("CreateQuote: %v", err)
+	}
+	return ID
+}
+
+func mustCreateUnverifiedQuote(t *testing.T, s Store, userID int32, teacherID int32) int32 {
+	ID, err := s.CreateUnverifiedQuote(UnverifiedQuoteT{UserID: userID, TeacherID: teacherID, TeacherName: "name", Text: "quote"})
+	if err != nil {
+		t.Fatalf("CreateUnverifiedQuote: %v", err)
+	}
+	return ID
+}
+
+// mustCreateCredentials stores a session with the token hash "session <name>"
+// and an API token with the token hash "token <name>" for the user
+func mustCreateCredentials(t *testing.T, s Store, userID int32, name string) {
+	err := s.CreateSession("session "+name, SessionT{UserID: userID, Expires: 20})
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	_, err = s.CreateToken("token "+name, TokenT{UserID: userID, Name: name, Scope: ScopeAll})
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+}
+
+func mustPutVote(t *testing.T, s Store, vote VoteT) {
+	if err := s.PutVote(vote); err != nil {
+		t.Fatalf("PutVote: %v", err)
+	}
+}
+
+func mustGetTeachers(t *testing.T, s Store) []TeacherT {
+	teachers, err := s.GetTeachers()
+	if err != nil {
+		t.Fatalf("GetTeachers: %v", err)
+	}
+	return teachers
+}
+
+func mustGetVotes(t *testing.T, s Store) []VoteT {
+	votes, err := s.GetVotes()
+	if err != nil {
+		t.Fatalf("GetVotes: %v", err)
+	}
+	return votes
+}
+
+func isInvalidQuoteID(err error) bool {
+	_, ok := err.(InvalidQuoteIDError)
+	return ok
+}
+
+func isInvalidTeacherID(err error) bool {
+	_, ok := err.(InvalidTeacherIDError)
+	return ok
+}
+
+func isInvalidUserID(err error) bool {
+	_, ok := err.(InvalidUserIDError)
+	return ok
+}
+
+func isTeacherInUse(err error) bool {
+	_, ok := err.(TeacherInUseError)
+	return ok
+}
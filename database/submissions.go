@@ -0,0 +1,140 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// RejectedQuoteT stores one rejected unverified quote, so that its submitter learns why
+// UnverifiedQuoteT  the unverified quote as it was rejected, QuoteID stays the same
+// Reason            one of the predefined reasons
+// Note              an explanation by the moderator, optional unless Reason is ReasonOther
+// Rejected          the unixtime of the rejection
+type RejectedQuoteT struct {
+	UnverifiedQuoteT
+	Reason   ReasonT
+	Note     string
+	Rejected int64
+}
+
+// ReasonT is the reason an unverified quote was rejected for
+type ReasonT string
+
+// SubmissionT describes one quote a user submitted and what became of it
+// State             whether the quote is still pending, was confirmed or rejected
+// UnverifiedQuoteT  the quote as submitted (pending) or rejected, the quote as it is now if confirmed.
+//                   QuoteID is the QuoteID of the confirmed quote if confirmed.
+// Reason, Note      why the quote was rejected, see RejectedQuoteT
+type SubmissionT struct {
+	State SubmissionStateT
+	UnverifiedQuoteT
+	Reason ReasonT
+	Note   string
+}
+
+// SubmissionStateT is the state of a submitted quote
+type SubmissionStateT string
+
+/* -------------------------------------------------------------------------- */
+/*                                  CONSTANTS                                 */
+/* -------------------------------------------------------------------------- */
+
+// The reasons for rejecting an unverified quote
+const (
+	// ReasonDuplicate is used if the quote has already been submitted or confirmed
+	ReasonDuplicate ReasonT = "duplicate"
+	// ReasonOffensive is used if the quote insults or exposes someone
+	ReasonOffensive ReasonT = "offensive"
+	// ReasonUnclear is used if the quote can't be understood or assigned to a teacher
+	ReasonUnclear ReasonT = "unclear"
+	// ReasonOther is used for everything else, Note has to explain it
+	ReasonOther ReasonT = "other"
+)
+
+// The states of a submitted quote
+const (
+	SubmissionPending   SubmissionStateT = "pending"
+	SubmissionConfirmed SubmissionStateT = "confirmed"
+	SubmissionRejected  SubmissionStateT = "rejected"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                        EXPORTED SUBMISSIONS FUNCTIONS                      */
+/* -------------------------------------------------------------------------- */
+
+// Reasons returns all valid reasons for rejecting an unverified quote
+func Reasons() []ReasonT {
+	return []ReasonT{ReasonDuplicate, ReasonOffensive, ReasonUnclear, ReasonOther}
+}
+
+// IsValid checks if reason is one of the defined reasons
+func (reason ReasonT) IsValid() bool {
+	for _, r := range Reasons() {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// RejectUnverifiedQuote moves an unverified quote to the rejected quotes,
+// where its submitter can see the reason.
+// The unverified quote is deleted in the same transaction, so it can't end up in both tables.
+//
+// Possible returned error types: generic / DBError / InvalidQuoteIDError
+func RejectUnverifiedQuote(ID int32, reason ReasonT, note string) error {
+	if store == nil {
+		return errors.New("RejectUnverifiedQuote: not connected to database")
+	}
+
+	if ID == 0 {
+		return InvalidQuoteIDError{"RejectUnverifiedQuote: QuoteID is zero"}
+	}
+
+	if !reason.IsValid() {
+		return fmt.Errorf("RejectUnverifiedQuote: invalid Reason %q", reason)
+	}
+
+	if reason == ReasonOther && note == "" {
+		return errors.New("RejectUnverifiedQuote: Note is required for Reason other")
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	// Verify connection to database
+	err := store.Ping()
+	if err != nil {
+		store.Close()
+		return DBError{"RejectUnverifiedQuote: pinging database failed", err}
+	}
+
+	return store.RejectUnverifiedQuote(ID, reason, note, time.Now().Unix())
+}
+
+// GetSubmissions returns the pending, confirmed and rejected quotes submitted by the user
+// with the given UserID, newest first. Quotes confirmed before submitters were remembered are missing.
+//
+// Possible returned error types: generic / DBError
+func GetSubmissions(userID int32) ([]SubmissionT, error) {
+	if store == nil {
+		return nil, errors.New("GetSubmissions: not connected to database")
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	submissions, err := store.GetSubmissions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(submissions, func(i, j int) bool { return submissions[i].Unixtime > submissions[j].Unixtime })
+	return submissions, nil
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// TokenT stores one personal API token, which scripts can use instead of a password
+// TokenID  the unique identifier of the token
+// UserID   the unique ID of the user the token belongs to
+// Name     a description chosen by the user, e.g. what the token is used for
+// Scope    what the token may be used for
+// Created  the unixtime at which the token was created
+// Expires  the unixtime after which the token is no longer valid, 0 if it never expires
+//
+// Like session tokens, the token itself is only shown once when creating it,
+// the database stores its hash (see hashToken)
+type TokenT struct {
+	TokenID int32
+	UserID  int32
+	Name    string
+	Scope   ScopeT
+	Created int64
+	Expires int64
+}
+
+// ScopeT limits what an API token may be used for,
+// the user's role still applies on top of it
+type ScopeT string
+
+/* -------------------------------------------------------------------------- */
+/*                                  CONSTANTS                                 */
+/* -------------------------------------------------------------------------- */
+
+// The scopes an API token can have
+const (
+	// ScopeAll allows everything the user is permitted to do
+	ScopeAll ScopeT = "all"
+	// ScopeRead only allows reading
+	ScopeRead ScopeT = "read"
+	// ScopeSubmit only allows submitting quotes
+	ScopeSubmit ScopeT = "submit"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                          EXPORTED TOKENS FUNCTIONS                         */
+/* -------------------------------------------------------------------------- */
+
+// Scopes returns all valid scopes
+func Scopes() []ScopeT {
+	return []ScopeT{ScopeAll, ScopeRead, ScopeSubmit}
+}
+
+// IsValid checks if scope is one of the defined scopes
+func (scope ScopeT) IsValid() bool {
+	for _, s := range Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTokens returns the API tokens of the user with the given UserID, sorted by TokenID
+//
+// Possible returned error types: generic / DBError
+func GetTokens(userID int32) ([]TokenT, error) {
+	if store == nil {
+		return nil, errors.New("GetTokens: not connected to database")
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	tokens, err := store.GetTokens(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].TokenID < tokens[j].TokenID })
+	return tokens, nil
+}
+
+// CreateToken creates a new API token from t (TokenID and Created are ignored)
+// and returns its TokenID and the token itself, which needs to be presented to GetTokenUserID.
+//
+// Possible returned error types: generic / DBError / InvalidUserIDError
+func CreateToken(t TokenT) (int32, string, error) {
+	if store == nil {
+		return 0, "", errors.New("CreateToken: not connected to database")
+	}
+
+	if t.UserID < 1 {
+		return 0, "", InvalidUserIDError{"CreateToken: invalid UserID, must be greater than zero"}
+	}
+
+	if t.Scope == "" {
+		t.Scope = ScopeAll
+	}
+
+	if !t.Scope.IsValid() {
+		return 0, "", fmt.Errorf("CreateToken: invalid Scope %q", t.Scope)
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return 0, "", errors.New("CreateToken: generating token failed: " + err.Error())
+	}
+
+	t.Created = time.Now().Unix()
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	// Verify connection to database
+	err = store.Ping()
+	if err != nil {
+		return 0, "", DBError{"CreateToken: pinging database failed", err}
+	}
+
+	id, err := store.CreateToken(hashToken(token), t)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return id, token, nil
+}
+
+// GetTokenUserID returns the UserID and Scope of the API token
+// or 0 if there is no such token, it has expired or its user has been disabled
+//
+// Possible returned error types: -
+func GetTokenUserID(token string) (int32, ScopeT) {
+	if store == nil || token == "" {
+		return 0, ""
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	t, err := store.GetToken(hashToken(token))
+	if err != nil || (t.Expires != 0 && t.Expires < time.Now().Unix()) {
+		return 0, ""
+	}
+
+	user, ok := unsafeGetUserByIDFromCache(t.UserID)
+	if !ok || user.Disabled {
+		return 0, ""
+	}
+
+	return t.UserID, t.Scope
+}
+
+// DeleteToken revokes the API token with the given TokenID of the user with the given UserID
+//
+// Possible returned error types: generic / DBError / InvalidTokenIDError
+func DeleteToken(userID int32, ID int32) error {
+	if store == nil {
+		return errors.New("DeleteToken: not connected to database")
+	}
+
+	if ID == 0 {
+		return InvalidTokenIDError{"DeleteToken: TokenID is zero"}
+	}
+
+	globalMutex.MinorLock()
+	defer globalMutex.MinorUnlock()
+
+	return store.DeleteToken(userID, ID)
+}
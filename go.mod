@@ -1,6 +1,11 @@
 module quote_gallery
 
-go 1.15
+go 1.19
 
 require github.com/lib/pq v1.8.0
-require github.com/gorilla/mux v1.8.0
\ No newline at end of file
+
+require github.com/gorilla/mux v1.8.0
+
+require github.com/mattn/go-sqlite3 v1.14.22
+
+require golang.org/x/crypto v0.21.0
@@ -1,17 +1,33 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"quote_gallery/database"
 	"quote_gallery/web"
 )
 
 func main() {
+	migrateOnly := flag.Bool("migrate", false, "apply pending database migrations and exit")
+	flag.Parse()
+
 	log.Print("Connecting to database")
-	database.Connect()
+	err := database.Connect()
+	if err != nil {
+		log.Fatal(err)
+	}
 	defer database.CloseAndClearCache()
 
-	err := database.Initialize()
+	if *migrateOnly {
+		err = database.Migrate()
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Print("Database is up to date")
+		return
+	}
+
+	err = database.Initialize()
 	if err != nil {
 		log.Fatal(err)
 	}
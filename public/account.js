@@ -0,0 +1,32 @@
+let form = document.getElementById("form-password");
+let passwordfield = document.getElementById("passwordfield");
+let passwordrepeatfield = document.getElementById("passwordrepeatfield");
+
+form.addEventListener("submit", processForm);
+
+function processForm(e) {
+  e.preventDefault();
+
+  if (passwordfield.value != passwordrepeatfield.value) {
+    alert("Die Passwörter stimmen nicht überein!");
+    return true;
+  }
+
+  let req = {};
+  req["OldPassword"] = document.getElementById("oldpasswordfield").value;
+  req["NewPassword"] = passwordfield.value;
+
+  axios.put("/api/account/password", req)
+    .then(function (res) {
+      if (!res.data.LoggedIn) {
+        alert("Passwort geändert! Bitte melde dich neu an.");
+        window.location = "/login";
+        return;
+      }
+      alert("Passwort geändert!");
+      form.reset();
+    })
+    .catch(axiosErrorHandler.bind(this, "Passwort-Ändern"));
+
+  return true;
+}
@@ -20,21 +20,6 @@ function http(method, url) {
 }
 
 
-function axiosErrorString(response) {
-  if (!response) {
-    return "Keine Antwort erhalten";
-  }
-
-  let errorstr = "";
-  if (response.status) {
-    errorstr = "Status: " + response.status;
-  }
-  if (response.data) {
-    errorstr += "\nAntwort: " + response.data;
-  }
-  return errorstr;
-}
-
 let teacherselect = document.getElementById("teacherselect");
 let customteacher = document.getElementsByClassName("customteacher")[0];
 let customteacherfield = document.getElementById("customteacherfield");
@@ -53,3 +38,220 @@ function assignTeacher(quoteid) {
   http("put","/api/unverifiedquotes/" + quoteid + "/assignteacher/" + teacherid);
   return undefined;
 }
+
+function rejectQuote(quoteid) {
+  let reason = document.getElementById("reasonselect-" + quoteid).value;
+  if (!reason) {
+    alert("Keinen Grund ausgewählt!");
+    return undefined;
+  }
+  // the note is shown to the submitter, it is required for "other"
+  let note = prompt("Anmerkung für die Person, die das Zitat eingesendet hat" + (reason == "other" ? ":" : " (optional):"));
+  if (note === null) {
+    return undefined;
+  }
+  axios.put("/api/unverifiedquotes/" + quoteid + "/reject", { Reason: reason, Note: note })
+    .then(function () {
+      window.location.reload();
+    })
+    .catch(axiosErrorHandler.bind(this, "Ablehnen"));
+  return undefined;
+}
+
+function selectAllQuotes(checked) {
+  for (let box of document.getElementsByClassName("bulkselect")) {
+    box.checked = checked;
+  }
+  return undefined;
+}
+
+function bulkModerate(action) {
+  let req = { QuoteIDs: [], Action: action };
+  for (let box of document.getElementsByClassName("bulkselect")) {
+    if (box.checked) {
+      req.QuoteIDs.push(parseInt(box.value));
+    }
+  }
+  if (req.QuoteIDs.length == 0) {
+    alert("Keine Zitate ausgewählt!");
+    return undefined;
+  }
+
+  if (action == "reject") {
+    req.Reason = document.getElementById("bulkreasonselect").value;
+    if (!req.Reason) {
+      alert("Keinen Grund ausgewählt!");
+      return undefined;
+    }
+    // the note is shown to every submitter, it is required for "other"
+    req.Note = prompt("Anmerkung für die Personen, die die Zitate eingesendet haben" + (req.Reason == "other" ? ":" : " (optional):"));
+    if (req.Note === null) {
+      return undefined;
+    }
+  } else if (action == "assignteacher") {
+    req.TeacherID = parseInt(document.getElementById("bulkteacherselect").value);
+    if (!req.TeacherID || isNaN(req.TeacherID)) {
+      alert("Keinen Lehrer ausgewählt!");
+      return undefined;
+    }
+  }
+
+  axios.post("/api/unverifiedquotes/bulk", req)
+    .then(function () {
+      window.location.reload();
+    })
+    .catch(function (err) {
+      // nothing was changed, list the quotes which prevented it
+      if (err.response && err.response.data.code == "bulk_failed") {
+        let failures = err.response.data.Results
+          .filter(function (result) { return result.code; })
+          .map(function (result) { return "#" + result.QuoteID + ": " + result.error; });
+        alert("Nichts geändert, fehlgeschlagen für:\n" + failures.join("\n"));
+        return;
+      }
+      axiosErrorHandler("Sammel-Bearbeiten", err);
+    });
+  return undefined;
+}
+
+function updateUser(userid, data) {
+  axios.put("/api/users/" + userid, data)
+    .then(function () {
+      window.location.reload();
+    })
+    .catch(axiosErrorHandler.bind(this, "Benutzer-Bearbeiten"));
+  return undefined;
+}
+
+function resetPassword(userid, name) {
+  let password = prompt("Neues Passwort für " + name + ":");
+  if (!password) {
+    return undefined;
+  }
+  updateUser(userid, { Password: password });
+  return undefined;
+}
+
+function resetLink(userid) {
+  axios.post("/api/users/" + userid + "/resetlink")
+    .then(function (res) {
+      // the token is only shown this one time
+      let link = window.location.origin + "/reset?token=" + res.data.Token;
+      prompt("Link zum Zurücksetzen des Passworts (wird nur jetzt angezeigt, nur einmal nutzbar):", link);
+    })
+    .catch(axiosErrorHandler.bind(this, "Reset-Link-Erstellen"));
+  return undefined;
+}
+
+function deleteUser(userid, name) {
+  if (!confirm(name + " mitsamt Einsendungen und Bewertungen löschen?")) {
+    return undefined;
+  }
+  http("delete", "/api/users/" + userid);
+  return undefined;
+}
+
+function deleteQuote(quoteid) {
+  if (!confirm("Zitat #" + quoteid + " mitsamt Bewertungen löschen?")) {
+    return undefined;
+  }
+  http("delete", "/api/quotes/" + quoteid);
+  return undefined;
+}
+
+function deleteTeacher(teacherid, name) {
+  if (!confirm(name + " löschen?")) {
+    return undefined;
+  }
+  axios.delete("/api/teachers/" + teacherid)
+    .then(function () {
+      window.location.reload();
+    })
+    .catch(function (err) {
+      // the teacher still has quotes, which are only deleted on request
+      if (err.response && err.response.data.code == "teacher_in_use") {
+        if (confirm(name + " hat noch Zitate. " + name + " mitsamt Zitaten und Bewertungen löschen?")) {
+          http("delete", "/api/teachers/" + teacherid + "?cascade=true");
+        }
+        return;
+      }
+      axiosErrorHandler("Lehrer-Löschen", err);
+    });
+  return undefined;
+}
+
+function mergeTeacher(teacherid) {
+  let mergesel = document.getElementById("mergeselect-" + teacherid);
+  let otherid = parseInt(mergesel.value);
+  if (!otherid || isNaN(otherid)) {
+    alert("Keinen Lehrer ausgewählt!");
+    return undefined;
+  }
+  if (!confirm("Alle Zitate von Lehrer #" + teacherid + " Lehrer #" + otherid + " zuordnen und Lehrer #" + teacherid + " löschen?")) {
+    return undefined;
+  }
+  http("post", "/api/teachers/" + otherid + "/merge/" + teacherid);
+  return undefined;
+}
+
+function updateRateLimit(name) {
+  let req = {};
+  req["Burst"] = parseInt(document.getElementById("ratelimitburst-" + name).value);
+  req["PerHour"] = parseInt(document.getElementById("ratelimitperhour-" + name).value);
+
+  axios.put("/api/ratelimits/" + encodeURIComponent(name), req)
+    .then(function () {
+      window.location.reload();
+    })
+    .catch(axiosErrorHandler.bind(this, "Rate-Limit-Speichern"));
+  return undefined;
+}
+
+function unlock(kind, key) {
+  axios.delete("/api/lockouts", { params: { [kind]: key } })
+    .then(function () {
+      window.location.reload();
+    })
+    .catch(axiosErrorHandler.bind(this, "Entsperren"));
+  return undefined;
+}
+
+// the user and invite forms are only shown to admins
+let adduserform = document.getElementById("form-adduser");
+
+adduserform && adduserform.addEventListener("submit", function (e) {
+  e.preventDefault();
+
+  let req = {};
+  req["Name"] = document.getElementById("usernamefield").value;
+  req["Password"] = document.getElementById("userpasswordfield").value;
+  req["Role"] = document.getElementById("userroleselect").value;
+
+  axios.post("/api/users", req)
+    .then(function () {
+      window.location.reload();
+    })
+    .catch(axiosErrorHandler.bind(this, "Benutzer-Hinzufügen"));
+});
+
+let addinviteform = document.getElementById("form-addinvite");
+
+addinviteform && addinviteform.addEventListener("submit", function (e) {
+  e.preventDefault();
+
+  let days = parseInt(document.getElementById("invitedaysfield").value);
+
+  let req = {};
+  req["MaxUses"] = parseInt(document.getElementById("invitemaxusesfield").value);
+  req["Expires"] = Math.floor(Date.now() / 1000) + days * 24 * 60 * 60;
+  req["Role"] = document.getElementById("inviteroleselect").value;
+
+  axios.post("/api/invites", req)
+    .then(function (res) {
+      // the code is only shown this one time
+      let link = window.location.origin + "/register?code=" + res.data.Code;
+      prompt("Einladungslink (wird nur jetzt angezeigt):", link);
+      window.location.reload();
+    })
+    .catch(axiosErrorHandler.bind(this, "Einladungscode-Erstellen"));
+});
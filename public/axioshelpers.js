@@ -1,3 +1,7 @@
+// send the CSRF token embedded by the server with every request
+axios.defaults.headers.common["X-CSRF-Token"] =
+  document.querySelector('meta[name="csrf-token"]').content;
+
 function axiosErrorHandler(action, err) {
   if("response" in err) { // if the error is axios-generated
     alert("Fehler beim " + action + "!\n"+axiosErrorString(err.response));
@@ -16,9 +20,19 @@ function axiosErrorString(response) {
   if (response.status) {
     errorstr = "Status: " + response.status;
   }
-  if (response.data) {
+  if (response.data && response.data.error) { // ErrorT of the API
+    errorstr += "\nAntwort: " + response.data.error;
+  } else if (response.data) {
     errorstr += "\nAntwort: " + response.data;
   }
   return errorstr;
 }
 
+
+function logout() {
+  axios.post("/api/logout")
+    .then(function () {
+      window.location = "/login";
+    })
+    .catch(axiosErrorHandler.bind(this, "Abmelden"));
+}
@@ -0,0 +1,32 @@
+let form = document.getElementById("form-submit");
+
+let quotefield = document.getElementById("quotefield");
+let contextfield = document.getElementById("contextfield");
+let teacherselect = document.getElementById("teacherselect");
+
+form.addEventListener("submit", processForm);
+
+function processForm(e) {
+  e.preventDefault();
+
+  let req = {};
+  req["Text"] = quotefield.value;
+  req["Context"] = contextfield.value;
+  req["Teacher"] = parseInt(teacherselect.value);
+
+  axios.put(
+    "/api/quotes/" + window.location.pathname.split("/")[3],
+    req
+  ).then(function (res) {
+      if (res.status == 200) {
+        //hiding form because chrome re-shows last input values
+        document.getElementById("form-submit").style.display = "none";
+        window.location = "/admin/quotes";
+      } else {
+        return Promise.reject({ response: res });
+      }
+    })
+    .catch(axiosErrorHandler.bind(this, "Zitat-Abändern"));
+
+  return true;
+}
@@ -34,18 +34,3 @@ function processForm(e) {
   return true;
 }
 
-
-function axiosErrorString(response) {
-  if (!response) {
-    return "Keine Antwort erhalten";
-  }
-
-  let errorstr = "";
-  if (response.status) {
-    errorstr = "Status: " + response.status;
-  }
-  if (response.data) {
-    errorstr += "\nAntwort: " + response.data;
-  }
-  return errorstr;
-}
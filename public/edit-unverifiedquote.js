@@ -31,10 +31,8 @@ function processForm(e) {
     }
   }
 
-  axios.put(
-    "/api/unverifiedquotes/" + window.location.pathname.split("/")[3],
-    req
-  ).then(function (res) {
+  // moderators and submitters edit through different routes
+  axios.put(form.dataset.api, req).then(function (res) {
       if (res.status == 200) {
         //hiding form because chrome re-shows last input values
         document.getElementById("form-submit").style.display = "none";
@@ -0,0 +1,31 @@
+let form = document.getElementById("form-login");
+let passwordfield = document.getElementById("passwordfield");
+
+form.addEventListener("submit", processForm);
+
+function processForm(e) {
+  e.preventDefault();
+
+  let req = {};
+  req["Name"] = document.getElementById("namefield").value;
+  req["Password"] = passwordfield.value;
+
+  axios.post("/api/login", req)
+    .then(function (res) {
+      if (res.status == 200) {
+        window.location = form.dataset.next;
+      } else {
+        return Promise.reject({ response: res });
+      }
+    })
+    .catch(function (err) {
+      if (err.response && err.response.status == 401) {
+        passwordfield.value = "";
+        alert("Benutzername oder Passwort falsch!");
+        return;
+      }
+      axiosErrorHandler("Anmelden", err);
+    });
+
+  return true;
+}
@@ -0,0 +1,11 @@
+function withdraw(quoteid) {
+  if (!confirm("Zitat zurückziehen? Es wird dann nicht mehr geprüft.")) {
+    return undefined;
+  }
+  axios.delete("/api/me/submissions/" + quoteid)
+    .then(function () {
+      window.location.reload();
+    })
+    .catch(axiosErrorHandler.bind(this, "Zurückziehen"));
+  return undefined;
+}
@@ -1,7 +1,10 @@
 animationmap = {};
 
 function voteFor(button, quoteid, rating) {
-  if (button.classList.contains("selected")) return;
+  if (button.classList.contains("selected")) {
+    unvoteFor(button, quoteid);
+    return;
+  }
 
   for (sibling of button.parentElement.children) {
     if (sibling == button) {
@@ -55,3 +58,28 @@ function voteFor(button, quoteid, rating) {
       button.classList.remove("loading");
     });
 }
+
+function unvoteFor(button, quoteid) {
+  axios
+    .delete("/api/quotes/" + quoteid + "/vote")
+    .then(function (res) {
+      if (res.status == 200) {
+        button.classList.remove("selected");
+        if (res.data && "Num" in res.data) {
+          // without a vote of one's own, the vote amount isn't shown
+          for (let i = 0; i < 5; i++) {
+            let stats = button.parentElement.children[i].children[1];
+            stats.style.setProperty(
+              "--score",
+              res.data["Num"] ? res.data["Data"][i] / res.data["Num"] : 0
+            );
+            stats.style.removeProperty("opacity");
+          }
+        }
+        return Promise.resolve(res);
+      } else {
+        return Promise.reject(res);
+      }
+    })
+    .catch(axiosErrorHandler.bind(this, "Zurückziehen der Bewertung"));
+}
@@ -0,0 +1,31 @@
+let form = document.getElementById("form-register");
+let passwordfield = document.getElementById("passwordfield");
+let passwordrepeatfield = document.getElementById("passwordrepeatfield");
+
+form.addEventListener("submit", processForm);
+
+function processForm(e) {
+  e.preventDefault();
+
+  if (passwordfield.value != passwordrepeatfield.value) {
+    alert("Die Passwörter stimmen nicht überein!");
+    return true;
+  }
+
+  let req = {};
+  req["Code"] = document.getElementById("codefield").value.trim();
+  req["Name"] = document.getElementById("namefield").value;
+  req["Password"] = passwordfield.value;
+
+  axios.post("/api/register", req)
+    .then(function (res) {
+      if (res.status == 200) {
+        window.location = "/";
+      } else {
+        return Promise.reject({ response: res });
+      }
+    })
+    .catch(axiosErrorHandler.bind(this, "Registrieren"));
+
+  return true;
+}
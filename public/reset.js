@@ -0,0 +1,30 @@
+let form = document.getElementById("form-reset");
+let passwordfield = document.getElementById("passwordfield");
+let passwordrepeatfield = document.getElementById("passwordrepeatfield");
+
+form.addEventListener("submit", processForm);
+
+function processForm(e) {
+  e.preventDefault();
+
+  if (passwordfield.value != passwordrepeatfield.value) {
+    alert("Die Passwörter stimmen nicht überein!");
+    return true;
+  }
+
+  let req = {};
+  req["Token"] = document.getElementById("tokenfield").value;
+  req["Password"] = passwordfield.value;
+
+  axios.post("/api/reset", req)
+    .then(function (res) {
+      if (res.status == 200) {
+        window.location = "/";
+      } else {
+        return Promise.reject({ response: res });
+      }
+    })
+    .catch(axiosErrorHandler.bind(this, "Passwort-Zurücksetzen"));
+
+  return true;
+}
@@ -0,0 +1,33 @@
+function revokeToken(tokenid) {
+  if (!confirm("Token widerrufen? Skripte, die es benutzen, verlieren den Zugriff.")) {
+    return undefined;
+  }
+  axios.delete("/api/tokens/" + tokenid)
+    .then(function () {
+      window.location.reload();
+    })
+    .catch(axiosErrorHandler.bind(this, "Token-Widerrufen"));
+  return undefined;
+}
+
+let addtokenform = document.getElementById("form-addtoken");
+
+addtokenform.addEventListener("submit", function (e) {
+  e.preventDefault();
+
+  let days = parseInt(document.getElementById("tokendaysfield").value);
+
+  let req = {};
+  req["Name"] = document.getElementById("tokennamefield").value;
+  req["Scope"] = document.getElementById("tokenscopeselect").value;
+  // 0 means the token never expires
+  req["Expires"] = isNaN(days) ? 0 : Math.floor(Date.now() / 1000) + days * 24 * 60 * 60;
+
+  axios.post("/api/tokens", req)
+    .then(function (res) {
+      // the token is only shown this one time
+      prompt("API-Token (wird nur jetzt angezeigt):", res.data.Token);
+      window.location.reload();
+    })
+    .catch(axiosErrorHandler.bind(this, "Token-Erstellen"));
+});
@@ -3,10 +3,16 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 	"quote_gallery/database"
 )
 
 func main() {
+	// no live database required, unless DB_DRIVER says otherwise
+	if os.Getenv("DB_DRIVER") == "" {
+		os.Setenv("DB_DRIVER", "memory")
+	}
+
 	log.Print("Connecting to database")
 	database.Connect()
 
@@ -55,9 +61,9 @@ func main() {
 		Text:      "FFF DDD EEE",
 	})
 
-	database.DeleteTeacher(i[1].TeacherID)
+	database.DeleteTeacher(i[1].TeacherID, true)
 
-	j, _ := database.GetAllQuotes()
+	j, _ := database.GetNQuotesFrom(database.GetQuotesAmount(), 0)
 	fmt.Println(j)
 	database.PrintWordsMap()
 
@@ -66,7 +72,7 @@ func main() {
 
 	database.Initialize()
 
-	j, _ = database.GetAllQuotes()
+	j, _ = database.GetNQuotesFrom(database.GetQuotesAmount(), 0)
 	fmt.Println(j)
 	database.PrintWordsMap()
 
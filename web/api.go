@@ -5,458 +5,901 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
 	"net/http"
 	"quote_gallery/database"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 )
 
+/* -------------------------------------------------------------------------- */
+/*                                  CONSTANTS                                 */
+/* -------------------------------------------------------------------------- */
+
+// maxQuotesPerPage is the largest perPage GET /api/quotes accepts
+const maxQuotesPerPage = 100
+
+// defaultSimilarLimit is how many similar quotes are returned unless ?limit= is given
+const defaultSimilarLimit = 5
+
+// maxSimilarLimit is the largest ?limit= the similarity routes accept
+const maxSimilarLimit = 50
+
+// maxBulkQuotes is how many unverified quotes POST /api/unverifiedquotes/bulk accepts at once
+const maxBulkQuotes = 100
+
 /* -------------------------------------------------------------------------- */
 /*                                 DEFINITIONS                                */
 /* -------------------------------------------------------------------------- */
 
+// quoteT is a database.QuoteT with its teacher embedded, QuoteT in docs/apispec.tinyspec
+type quoteT struct {
+	QuoteID  int32
+	Teacher  database.TeacherT
+	Context  string
+	Text     string
+	Unixtime int64
+	Stats    database.QuoteStatsT
+	MyVote   int8
+}
+
+// quotesPageT is one page of quotes
+// Total  the number of quotes on all pages
+// Links  the URLs of other pages, Prev and Next are omitted on the first / last page
+type quotesPageT struct {
+	Quotes  []quoteT
+	Total   int
+	Page    int
+	PerPage int
+	Links   struct {
+		Self  string
+		First string
+		Prev  string `json:",omitempty"`
+		Next  string `json:",omitempty"`
+		Last  string
+	}
+}
+
+// similarQuoteT is a quoteT with how well it matches the searched text (see database.QuoteT)
+type similarQuoteT struct {
+	quoteT
+	Match float32
+}
+
+type similarInputT struct {
+	Text string
+}
+
 type quoteInputT struct {
 	Teacher interface{}
 	Context string
 	Text    string
 }
 
+// submissionT is a database.SubmissionT, SubmissionT in docs/apispec.tinyspec
+// SubmissionID  the QuoteID of the unverified (pending or rejected) quote, the :id of /api/me/submissions/:id
+// QuoteID       the QuoteID of the confirmed quote, the :id of /api/quotes/:id
+// Teacher       the TeacherT if the quote has a TeacherID, otherwise the TeacherName
+//
+// The two IDs come from different tables, so they are kept apart and only one of them is set.
+type submissionT struct {
+	State        database.SubmissionStateT
+	SubmissionID int32 `json:",omitempty"`
+	QuoteID      int32 `json:",omitempty"`
+	Teacher      interface{}
+	Context      string
+	Text         string
+	Unixtime     int64
+	Reason       database.ReasonT `json:",omitempty"`
+	Note         string           `json:",omitempty"`
+}
+
+// rejectInputT is why an unverified quote is rejected, Note is required for Reason "other"
+type rejectInputT struct {
+	Reason database.ReasonT
+	Note   string
+}
+
+// bulkInputT is a moderation action for several unverified quotes, see database.ModerationT
+type bulkInputT struct {
+	QuoteIDs  []int32
+	Action    database.ActionT
+	TeacherID int32
+	Reason    database.ReasonT
+	Note      string
+}
+
+// bulkResultT is the outcome of a bulk action for one unverified quote, BulkResultT in docs/apispec.tinyspec
+// Confirmed      the QuoteID of the new quote if the unverified quote was confirmed
+// Code, Message  why the action failed for this unverified quote, see apiErrorT
+type bulkResultT struct {
+	QuoteID   int32
+	Confirmed int32  `json:",omitempty"`
+	Code      string `json:"code,omitempty"`
+	Message   string `json:"error,omitempty"`
+}
+
 type teacherInputT struct {
 	Name  string
 	Title string
 	Note  string
 }
 
+type userInputT struct {
+	Name     string
+	Password string
+	Role     database.RoleT
+}
+
+// userUpdateInputT only contains the fields which are to be changed
+type userUpdateInputT struct {
+	Name     *string
+	Password *string
+	Role     *database.RoleT
+	Disabled *bool
+}
+
+type inviteInputT struct {
+	MaxUses int32
+	Expires int64
+	Role    database.RoleT
+}
+
+type registerInputT struct {
+	Code     string
+	Name     string
+	Password string
+}
+
+type loginInputT struct {
+	Name     string
+	Password string
+}
+
+type passwordChangeInputT struct {
+	OldPassword string
+	NewPassword string
+}
+
+type passwordResetInputT struct {
+	Token    string
+	Password string
+}
+
+type rateLimitInputT struct {
+	Burst   int32
+	PerHour int32
+}
+
+type tokenInputT struct {
+	Name    string
+	Scope   database.ScopeT
+	Expires int64
+}
+
 /* -------------------------------------------------------------------------- */
 /*                           EXPORTED API FUNCTIONS                           */
 /* -------------------------------------------------------------------------- */
 
-func postAPIQuotesSubmit(w http.ResponseWriter, r *http.Request, u int32) {
-	var subm quoteInputT
-	var quote database.UnverifiedQuoteT
-
-	// parse json request body into temporary QuoteInput
-	bytes, _ := ioutil.ReadAll(r.Body)
-	err := json.Unmarshal(bytes, &subm)
+// getAPIQuotes returns one page of quotes, sorted by ?sorting= (see database.IndexHandlers)
+// and optionally only those of the teacher given by ?teacher=
+func getAPIQuotes(w http.ResponseWriter, r *http.Request, u int32) {
+	query := r.URL.Query()
 
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "unparsable JSON")
+	sorting := database.DefaultIndexHandlerName
+	if query.Get("sorting") != "" {
+		sorting = query.Get("sorting")
+	}
+	indexHandler, ok := database.IndexHandlers[sorting]
+	if !ok {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "unknown sorting: %s", sorting)
 		return
 	}
 
-	// Check validity of temporary QuoteInput and
-	// copy content into UnverifiedQuote
+	page := 0
+	if query.Get("page") != "" {
+		var err error
+		page, err = strconv.Atoi(query.Get("page"))
+		if err != nil || page < 0 {
+			writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid page: %s", query.Get("page"))
+			return
+		}
+	}
 
-	switch subm.Teacher.(type) {
-	case float64:
-		if int32(subm.Teacher.(float64)) <= 0 {
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprintf(w, "invalid TeacherID: 0")
+	perPage := quotesPerPage
+	if query.Get("perPage") != "" {
+		var err error
+		perPage, err = strconv.Atoi(query.Get("perPage"))
+		if err != nil || perPage < 1 || perPage > maxQuotesPerPage {
+			writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid perPage: must be between 1 and %d", maxQuotesPerPage)
 			return
 		}
-		quote.TeacherID = int32(subm.Teacher.(float64))
-		quote.TeacherName = ""
-	case string:
-		quote.TeacherID = 0
-		quote.TeacherName = subm.Teacher.(string)
-	default:
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "invalid TeacherID: its type is neither string nor int")
-		return
 	}
 
-	if len(subm.Text) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Text is empty")
+	// page*perPage must not overflow
+	if page > (math.MaxInt-1)/perPage {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid page: %s", query.Get("page"))
 		return
 	}
 
-	quote.Context = subm.Context
-	quote.Text = subm.Text
+	var quotes []database.QuoteT
+	var total int
+	if query.Get("teacher") != "" {
+		teacherid, err := strconv.Atoi(query.Get("teacher"))
+		if err != nil || teacherid <= 0 {
+			writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid TeacherID: %s", query.Get("teacher"))
+			return
+		}
 
-	quote.UserID = u
+		_, err = database.GetTeacherByID(int32(teacherid))
+		if err != nil {
+			writeDatabaseError(w, r, err)
+			return
+		}
 
-	// Add further information to UnverifiedQuote
-	quote.Unixtime = int64(time.Now().Unix())
+		quotes, total, err = database.GetNSortedQuotesOfTeacherFrom(int32(teacherid), perPage, page*perPage, indexHandler.Function)
+		if err != nil {
+			writeDatabaseError(w, r, err)
+			return
+		}
+	} else {
+		var err error
+		total = database.GetQuotesAmount()
+		quotes, err = database.GetNSortedQuotesFrom(perPage, page*perPage, indexHandler.Function)
+		if err != nil {
+			writeDatabaseError(w, r, err)
+			return
+		}
+	}
 
-	// Store UnverifiedQuote in database
-	err = database.CreateUnverifiedQuote(quote)
+	err := database.AddUserDataToQuotes(quotes, u)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
 
+	apiQuotes, err := toAPIQuotes(quotes)
 	if err != nil {
-		switch err.(type) {
-		case database.InvalidTeacherIDError:
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprintf(w, "Teacher: no teacher with that ID")
-		default:
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "internal server error")
-			log.Printf("/api/quotes/submit: quote creation failed with error '%s' for request body '%s' and UnverifiedQuoteT %v", err.Error(), bytes, quote)
-		}
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	lastPage := 0
+	if total > 0 {
+		lastPage = (total - 1) / perPage
+	}
+
+	result := quotesPageT{Quotes: apiQuotes, Total: total, Page: page, PerPage: perPage}
+	result.Links.Self = quotesPageLink(r, page)
+	result.Links.First = quotesPageLink(r, 0)
+	result.Links.Last = quotesPageLink(r, lastPage)
+	if page > lastPage {
+		result.Links.Prev = quotesPageLink(r, lastPage)
+	} else if page > 0 {
+		result.Links.Prev = quotesPageLink(r, page-1)
+	}
+	if page < lastPage {
+		result.Links.Next = quotesPageLink(r, page+1)
 	}
+
+	writeJSON(w, r, result)
 }
 
-func putAPIUnverifiedQuotesID(w http.ResponseWriter, r *http.Request, u int32) {
+func getAPIQuotesID(w http.ResponseWriter, r *http.Request, u int32) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		// This should not happend, because this handler is only called if
 		// uri pattern is matched, see web.go
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "cannot convert in-url id to int")
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
 		return
 	}
-	if id == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "invalid QuoteID: 0")
+
+	quote, err := database.GetQuoteByID(int32(id))
+	if err != nil {
+		writeDatabaseError(w, r, err)
 		return
 	}
 
-	var subm quoteInputT
-	var quote database.UnverifiedQuoteT
-
-	// parse json request body into temporary QuoteInput
-	bytes, _ := ioutil.ReadAll(r.Body)
-	err = json.Unmarshal(bytes, &subm)
+	quotes := []database.QuoteT{quote}
+	err = database.AddUserDataToQuotes(quotes, u)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
 
+	apiQuotes, err := toAPIQuotes(quotes)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "unparsable JSON")
+		writeDatabaseError(w, r, err)
 		return
 	}
 
-	// Check validity of temporary QuoteInput and
-	// copy content into UnverifiedQuote
+	writeJSON(w, r, apiQuotes[0])
+}
 
-	switch subm.Teacher.(type) {
-	case float64:
-		if int32(subm.Teacher.(float64)) <= 0 {
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprintf(w, "invalid TeacherID: 0")
-			return
-		}
-		quote.TeacherID = int32(subm.Teacher.(float64))
-		quote.TeacherName = ""
-	case string:
-		quote.TeacherID = 0
-		quote.TeacherName = subm.Teacher.(string)
-	default:
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "invalid TeacherID: its type is neither string nor int")
+// postAPIQuotesSimilar returns the quotes most similar to the text, e.g. to warn about duplicates
+func postAPIQuotesSimilar(w http.ResponseWriter, r *http.Request, u int32) {
+	var subm similarInputT
+
+	if !decodeJSON(w, r, &subm) {
 		return
 	}
 
 	if len(subm.Text) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Text is empty")
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Text is empty")
 		return
 	}
 
-	quote.QuoteID = int32(id)
-	quote.Context = subm.Context
-	quote.Text = subm.Text
-
-	// Update UnverifiedQuote in database
-	err = database.UpdateUnverifiedQuote(quote)
-
-	if err != nil {
-		switch err.(type) {
-		case database.InvalidTeacherIDError:
-			w.WriteHeader(http.StatusNotFound)
-			fmt.Fprintf(w, "unknown TeacherID: %d", quote.TeacherID)
-		case database.InvalidQuoteIDError:
-			w.WriteHeader(http.StatusNotFound)
-			fmt.Fprintf(w, "unknown QuoteID: %d", quote.QuoteID)
-		default: //generic / database.DBError:
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "internal server error")
-			log.Printf("/api/unverifiedquotes/:id: quote updating failed with error '%s' for request body '%s' and UnverifiedQuoteT %v", err.Error(), bytes, quote)
-		}
-	}
+	writeSimilarQuotes(w, r, u, subm.Text)
 }
 
-func deleteAPIUnverifiedQuotesID(w http.ResponseWriter, r *http.Request, u int32) {
+// putAPIQuotesID edits a confirmed quote, its teacher has to be given by TeacherID
+func putAPIQuotesID(w http.ResponseWriter, r *http.Request, u int32) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		// This should not happend, because this handler is only called if
 		// uri pattern is matched, see web.go
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "cannot convert in-url id to int")
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
 		return
 	}
 	if id == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "invalid QuoteID: 0")
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid QuoteID: 0")
 		return
 	}
 
-	// Delete UnverifiedQuote from database
-	err = database.DeleteUnverifiedQuote(int32(id))
+	var subm quoteInputT
+
+	if !decodeJSON(w, r, &subm) {
+		return
+	}
+
+	teacherid, ok := subm.Teacher.(float64)
+	if !ok || int32(teacherid) <= 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid TeacherID: confirmed quotes need the ID of an existing teacher")
+		return
+	}
+
+	if len(subm.Text) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Text is empty")
+		return
+	}
+
+	quote := database.QuoteT{
+		QuoteID:   int32(id),
+		TeacherID: int32(teacherid),
+		Context:   subm.Context,
+		Text:      subm.Text,
+	}
+
+	err = database.UpdateQuote(quote)
 
 	if err != nil {
-		switch err.(type) {
-		case database.InvalidQuoteIDError:
-			w.WriteHeader(http.StatusNotFound)
-			fmt.Fprintf(w, "unknown QuoteID: %d", id)
-		default:
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "internal server error")
-			log.Printf("/api/unverifiedquotes/:id: quote deletion failed with error '%s'", err.Error())
-		}
+		writeDatabaseError(w, r, err)
 	}
 }
 
-func putAPIUnverifiedQuotesIDConfirm(w http.ResponseWriter, r *http.Request, u int32) {
+// deleteAPIQuotesID deletes a confirmed quote together with its votes
+func deleteAPIQuotesID(w http.ResponseWriter, r *http.Request, u int32) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		// This should not happend, because this handler is only called if
 		// uri pattern is matched, see web.go
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "cannot convert in-url id to int")
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
 		return
 	}
-
 	if id == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "invalid QuoteID: 0")
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid QuoteID: 0")
 		return
 	}
 
-	q, err := database.GetUnverifiedQuoteByID(int32(id))
+	err = database.DeleteQuote(int32(id))
 
 	if err != nil {
-		switch err.(type) {
-		case database.InvalidQuoteIDError:
-			w.WriteHeader(http.StatusNotFound)
-			fmt.Fprintf(w, "unknown QuoteID: %d", id)
-			return
-		default:
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "internal server error")
-			log.Printf("/api/unverifiedquotes/:id/confirm: getting unverified quotes failed with error '%s'", err.Error())
-			return
-		}
+		writeDatabaseError(w, r, err)
 	}
+}
 
-	if q.TeacherID == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "unverifiedQuote has invalid TeacherID (it needs a valid one to be confirmed)")
+func postAPIQuotesSubmit(w http.ResponseWriter, r *http.Request, u int32) {
+	quote, ok := readQuoteInput(w, r)
+	if !ok {
 		return
 	}
 
-	err = database.CreateQuote(database.QuoteT{
-		TeacherID: q.TeacherID,
-		Context:   q.Context,
-		Text:      q.Text,
-		Unixtime:  q.Unixtime,
-	})
+	quote.UserID = u
+
+	// Add further information to UnverifiedQuote
+	quote.Unixtime = int64(time.Now().Unix())
+
+	// Store UnverifiedQuote in database
+	err := database.CreateUnverifiedQuote(quote)
 
 	if err != nil {
-		switch err.(type) {
-		case database.InvalidTeacherIDError:
-			w.WriteHeader(http.StatusNotFound)
-			fmt.Fprintf(w, "unknown TeacherID: %d", id)
-			return
-		default:
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "internal server error")
-			log.Printf("/api/unverifiedquotes/:id/confirm: quote creation failed with error '%s'", err.Error())
-			return
-		}
+		writeDatabaseError(w, r, err)
 	}
-
-	database.DeleteUnverifiedQuote(int32(id))
 }
 
-func putAPIUnverifiedQuotesIDAssignTeacherID(w http.ResponseWriter, r *http.Request, u int32) {
-	quoteid, err := strconv.Atoi(mux.Vars(r)["quoteid"])
+func putAPIUnverifiedQuotesID(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		// This should not happen because this handler is only called if
+		// This should not happend, because this handler is only called if
 		// uri pattern is matched, see web.go
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "cannot convert in-url id to int")
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
 		return
 	}
-
-	if quoteid == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "invalid QuoteID: 0")
+	if id == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid QuoteID: 0")
 		return
 	}
 
-	teacherid, err := strconv.Atoi(mux.Vars(r)["teacherid"])
-	if err != nil {
-		// This should not happen, see above
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "cannot convert in-url id to int")
+	quote, ok := readQuoteInput(w, r)
+	if !ok {
 		return
 	}
 
-	if teacherid == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "invalid TeacherID: 0")
-		return
-	}
+	quote.QuoteID = int32(id)
 
-	q, err := database.GetUnverifiedQuoteByID(int32(quoteid))
+	// Update UnverifiedQuote in database
+	err = database.UpdateUnverifiedQuote(quote)
 
 	if err != nil {
-		switch err.(type) {
-		case database.InvalidQuoteIDError:
-			w.WriteHeader(http.StatusNotFound)
-			fmt.Fprintf(w, "unknown QuoteID: %d", quoteid)
-			return
-		default:
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "internal server error")
-			log.Printf("/api/unverifiedquotes/:quoteid/assignteacher/:teacherid: getting quote failed with error '%s'", err.Error())
-			return
-		}
+		writeDatabaseError(w, r, err)
 	}
+}
 
-	q.TeacherID = int32(teacherid)
-	q.TeacherName = ""
+func deleteAPIUnverifiedQuotesID(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return
+	}
+	if id == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid QuoteID: 0")
+		return
+	}
 
-	err = database.UpdateUnverifiedQuote(q)
+	// Delete UnverifiedQuote from database
+	err = database.DeleteUnverifiedQuote(int32(id))
 
 	if err != nil {
-		switch err.(type) {
-		case database.InvalidTeacherIDError:
-			w.WriteHeader(http.StatusNotFound)
-			fmt.Fprintf(w, "unknown TeacherID: %d", teacherid)
-			return
-		case database.InvalidQuoteIDError:
-			w.WriteHeader(http.StatusNotFound)
-			fmt.Fprintf(w, "unknown QuoteID: %d", quoteid)
-			return
-		default:
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "internal server error")
-			log.Printf("/api/unverifiedquotes/:quoteid/assignteacher/:teacherid: assigning teacher failed with error '%s'", err.Error())
-			return
-		}
+		writeDatabaseError(w, r, err)
 	}
 }
 
-func postAPITeachers(w http.ResponseWriter, r *http.Request, u int32) {
-	var subm teacherInputT
-	var teacher database.TeacherT
-
-	// parse json request body into temporary QuoteInput
-	bytes, _ := ioutil.ReadAll(r.Body)
-	err := json.Unmarshal(bytes, &subm)
-
+// getAPIUnverifiedQuotesIDSimilar returns the quotes most similar to an unverified quote,
+// which are likely duplicates of it
+func getAPIUnverifiedQuotesIDSimilar(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "unparsable JSON")
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
 		return
 	}
 
-	if len(subm.Name) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Name is empty")
+	quote, err := database.GetUnverifiedQuoteByID(int32(id))
+	if err != nil {
+		writeDatabaseError(w, r, err)
 		return
 	}
 
-	if len(subm.Title) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Title is empty")
+	writeSimilarQuotes(w, r, u, quote.Text)
+}
+
+func putAPIUnverifiedQuotesIDConfirm(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
 		return
 	}
 
-	teacher.Name = subm.Name
-	teacher.Title = subm.Title
-	teacher.Note = subm.Note
+	if id == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid QuoteID: 0")
+		return
+	}
 
-	err = database.CreateTeacher(teacher)
+	// move UnverifiedQuote to quotes in one transaction
+	quoteid, err := database.ConfirmUnverifiedQuote(int32(id))
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "internal server error")
-		log.Printf("/api/teachers: creating teacher failed with error '%s' for request body '%s' and TeacherT %v", err.Error(), bytes, teacher)
+		if _, ok := err.(database.InvalidTeacherIDError); ok {
+			// the unverified quote lacks a teacher, nothing in the request is unknown
+			writeDatabaseErrorStatus(w, r, err, http.StatusBadRequest, "the unverified quote needs a valid teacher to be confirmed")
+			return
+		}
+		writeDatabaseError(w, r, err)
 		return
 	}
+
+	writeJSON(w, r, struct{ QuoteID int32 }{quoteid})
 }
 
-func putAPITeachersID(w http.ResponseWriter, r *http.Request, u int32) {
+// putAPIUnverifiedQuotesIDReject rejects an unverified quote,
+// its submitter gets to see the reason on their submissions page
+func putAPIUnverifiedQuotesIDReject(w http.ResponseWriter, r *http.Request, u int32) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		// This should not happend, because this handler is only called if
 		// uri pattern is matched, see web.go
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "cannot convert in-url id to int")
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
 		return
 	}
 
 	if id == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "invalid TeacherID: 0")
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid QuoteID: 0")
 		return
 	}
 
-	var subm teacherInputT
-	var teacher database.TeacherT
-
-	// parse json request body into temporary QuoteInput
-	bytes, _ := ioutil.ReadAll(r.Body)
-	err = json.Unmarshal(bytes, &subm)
+	var subm rejectInputT
 
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "unparsable JSON")
+	// parse json request body into temporary rejectInput
+	if !decodeJSON(w, r, &subm) {
 		return
 	}
 
-	if len(subm.Name) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Name is empty")
+	if !subm.Reason.IsValid() {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid Reason: %s", subm.Reason)
 		return
 	}
 
-	if len(subm.Title) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Title is empty")
+	subm.Note = strings.TrimSpace(subm.Note)
+	if subm.Reason == database.ReasonOther && len(subm.Note) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Note is empty, but required for Reason %s", subm.Reason)
 		return
 	}
 
-	teacher.TeacherID = int32(id)
-	teacher.Name = subm.Name
-	teacher.Title = subm.Title
-	teacher.Note = subm.Note
-
-	err = database.UpdateTeacher(teacher)
+	// move UnverifiedQuote to rejected quotes in one transaction
+	err = database.RejectUnverifiedQuote(int32(id), subm.Reason, subm.Note)
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "internal server error")
-		log.Printf("/api/teachers: updating teacher failed with error '%s' for request body '%s' and TeacherT %v", err.Error(), bytes, teacher)
-		return
+		writeDatabaseError(w, r, err)
 	}
 }
 
-func putAPIQuotesIDVoteRating(w http.ResponseWriter, r *http.Request, u int32) {
-	quoteid, err := strconv.Atoi(mux.Vars(r)["id"])
+func putAPIUnverifiedQuotesIDAssignTeacherID(w http.ResponseWriter, r *http.Request, u int32) {
+	quoteid, err := strconv.Atoi(mux.Vars(r)["quoteid"])
 	if err != nil {
 		// This should not happend, because this handler is only called if
 		// uri pattern is matched, see web.go
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "cannot convert in-url id to int")
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
 		return
 	}
 
 	if quoteid == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "invalid QuoteID: 0")
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid QuoteID: 0")
 		return
 	}
 
-	val, err := strconv.Atoi(mux.Vars(r)["val"])
+	teacherid, err := strconv.Atoi(mux.Vars(r)["teacherid"])
 	if err != nil {
-		// This should not happend, because this handler is only called if
-		// uri pattern is matched, see web.go
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "cannot convert in-url vote val to int")
+		// This should not happen, see above
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return
+	}
+
+	if teacherid == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid TeacherID: 0")
+		return
+	}
+
+	q, err := database.GetUnverifiedQuoteByID(int32(quoteid))
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	q.TeacherID = int32(teacherid)
+	q.TeacherName = ""
+
+	err = database.UpdateUnverifiedQuote(q)
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+}
+
+// postAPIUnverifiedQuotesBulk applies one action to several unverified quotes in one transaction.
+// Either all of them succeed, or nothing is changed and a 409 Conflict lists which ones failed.
+func postAPIUnverifiedQuotesBulk(w http.ResponseWriter, r *http.Request, u int32) {
+	var subm bulkInputT
+
+	// parse json request body into temporary bulkInput
+	if !decodeJSON(w, r, &subm) {
+		return
+	}
+
+	if len(subm.QuoteIDs) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "QuoteIDs is empty")
+		return
+	}
+
+	if len(subm.QuoteIDs) > maxBulkQuotes {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "more than %d QuoteIDs", maxBulkQuotes)
+		return
+	}
+
+	seen := make(map[int32]bool, len(subm.QuoteIDs))
+	for _, id := range subm.QuoteIDs {
+		if id <= 0 {
+			writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid QuoteID: %d", id)
+			return
+		}
+		if seen[id] {
+			writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "duplicate QuoteID: %d", id)
+			return
+		}
+		seen[id] = true
+	}
+
+	m := database.ModerationT{Action: subm.Action}
+
+	switch subm.Action {
+	case database.ActionConfirm:
+	case database.ActionReject:
+		if !subm.Reason.IsValid() {
+			writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid Reason: %s", subm.Reason)
+			return
+		}
+
+		subm.Note = strings.TrimSpace(subm.Note)
+		if subm.Reason == database.ReasonOther && len(subm.Note) == 0 {
+			writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Note is empty, but required for Reason %s", subm.Reason)
+			return
+		}
+
+		m.Reason, m.Note = subm.Reason, subm.Note
+	case database.ActionAssignTeacher:
+		if subm.TeacherID <= 0 {
+			writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid TeacherID: %d", subm.TeacherID)
+			return
+		}
+
+		m.TeacherID = subm.TeacherID
+	default:
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid Action: %s", subm.Action)
+		return
+	}
+
+	results, err := database.ModerateUnverifiedQuotes(subm.QuoteIDs, m)
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	// convert to response
+	failed := 0
+	response := make([]bulkResultT, len(results))
+	for i, result := range results {
+		response[i].QuoteID = result.QuoteID
+		response[i].Confirmed = result.Quote.QuoteID
+
+		if result.Err == nil {
+			continue
+		}
+
+		status, apiErr := databaseError(result.Err)
+		logDatabaseError(r, status, result.Err)
+		if _, ok := result.Err.(database.InvalidTeacherIDError); ok && subm.Action == database.ActionConfirm {
+			// like PUT /api/unverifiedquotes/:id/confirm
+			apiErr.Message = "the unverified quote needs a valid teacher to be confirmed"
+		}
+
+		response[i].Code = apiErr.Code
+		response[i].Message = apiErr.Message
+		failed++
+	}
+
+	if failed > 0 {
+		writeJSONStatus(w, r, http.StatusConflict, struct {
+			apiErrorT
+			Results []bulkResultT
+		}{
+			apiErrorT{codeBulkFailed, fmt.Sprintf("%s failed for %d of %d unverified quotes, nothing was changed", subm.Action, failed, len(results))},
+			response,
+		})
+		return
+	}
+
+	writeJSON(w, r, struct{ Results []bulkResultT }{response})
+}
+
+// getAPITeachers returns all teachers, sorted by TeacherID
+func getAPITeachers(w http.ResponseWriter, r *http.Request, u int32) {
+	teachers, err := database.GetTeachers()
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	sort.Slice(teachers, func(i, j int) bool { return teachers[i].TeacherID < teachers[j].TeacherID })
+
+	writeJSON(w, r, teachers)
+}
+
+func getAPITeachersID(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return
+	}
+
+	teacher, err := database.GetTeacherByID(int32(id))
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, teacher)
+}
+
+func postAPITeachers(w http.ResponseWriter, r *http.Request, u int32) {
+	var subm teacherInputT
+	var teacher database.TeacherT
+
+	// parse json request body into temporary QuoteInput
+	if !decodeJSON(w, r, &subm) {
+		return
+	}
+
+	if len(subm.Name) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Name is empty")
+		return
+	}
+
+	if len(subm.Title) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Title is empty")
+		return
+	}
+
+	teacher.Name = subm.Name
+	teacher.Title = subm.Title
+	teacher.Note = subm.Note
+
+	err := database.CreateTeacher(teacher)
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+}
+
+func putAPITeachersID(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return
+	}
+
+	if id == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid TeacherID: 0")
+		return
+	}
+
+	var subm teacherInputT
+	var teacher database.TeacherT
+
+	// parse json request body into temporary QuoteInput
+	if !decodeJSON(w, r, &subm) {
+		return
+	}
+
+	if len(subm.Name) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Name is empty")
+		return
+	}
+
+	if len(subm.Title) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Title is empty")
+		return
+	}
+
+	teacher.TeacherID = int32(id)
+	teacher.Name = subm.Name
+	teacher.Title = subm.Title
+	teacher.Note = subm.Note
+
+	err = database.UpdateTeacher(teacher)
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+}
+
+// deleteAPITeachersID deletes a teacher, which must not have any (unverified) quotes
+// unless they are to be deleted too (?cascade=true)
+func deleteAPITeachersID(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return
+	}
+
+	if id == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid TeacherID: 0")
+		return
+	}
+
+	cascade := false
+	if r.URL.Query().Get("cascade") != "" {
+		cascade, err = strconv.ParseBool(r.URL.Query().Get("cascade"))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid cascade: %s", r.URL.Query().Get("cascade"))
+			return
+		}
+	}
+
+	err = database.DeleteTeacher(int32(id), cascade)
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+	}
+}
+
+// postAPITeachersIDMergeOtherID assigns all (unverified) quotes of the teacher
+// with otherid to the teacher with id and deletes the teacher with otherid
+func postAPITeachersIDMergeOtherID(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happen because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return
+	}
+
+	otherid, err := strconv.Atoi(mux.Vars(r)["otherid"])
+	if err != nil {
+		// This should not happen, see above
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return
+	}
+
+	if id == 0 || otherid == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid TeacherID: 0")
+		return
+	}
+
+	err = database.MergeTeachers(int32(id), int32(otherid))
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+	}
+}
+
+func putAPIQuotesIDVoteRating(w http.ResponseWriter, r *http.Request, u int32) {
+	quoteid, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return
+	}
+
+	if quoteid == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid QuoteID: 0")
+		return
+	}
+
+	val, err := strconv.Atoi(mux.Vars(r)["val"])
+	if err != nil {
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url vote val to int")
 		return
 	}
 
@@ -467,25 +910,850 @@ func putAPIQuotesIDVoteRating(w http.ResponseWriter, r *http.Request, u int32) {
 	})
 
 	if err != nil {
-		switch err.(type) {
-		case database.InvalidQuoteIDError:
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprintf(w, "unknown QuoteID: %d", quoteid)
-		default:
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, err.Error())
-			log.Printf("/api/quotes/:id/vote/:val: vote casting failed with error '%s' for QuoteID %d and rating value %d", err.Error(), quoteid, val)
-		}
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, quote.Stats)
+}
+
+// deleteAPIQuotesIDVote retracts the user's vote for a quote
+func deleteAPIQuotesIDVote(w http.ResponseWriter, r *http.Request, u int32) {
+	quoteid, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return
+	}
+
+	if quoteid == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid QuoteID: 0")
+		return
+	}
+
+	quote, err := database.DeleteVote(u, int32(quoteid))
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, quote.Stats)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                             SESSION API FUNCTIONS                          */
+/* -------------------------------------------------------------------------- */
+
+func postAPILogin(w http.ResponseWriter, r *http.Request) {
+	var login loginInputT
+
+	// parse json request body into temporary loginInput
+	if !decodeJSON(w, r, &login) {
 		return
 	}
 
-	b, err := json.Marshal(quote.Stats)
+	u, err := checkLogin(r, login.Name, login.Password, time.Now())
+	if tooMany, ok := err.(tooManyAttemptsError); ok {
+		denyTooManyAttempts(w, r, tooMany)
+		return
+	}
+	if u == 0 {
+		writeAPIError(w, http.StatusUnauthorized, codeWrongPassword, "wrong username or password")
+		return
+	}
 
+	token, err := database.CreateSession(u, sessionDuration)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "marshalling to json failed")
+		writeDatabaseError(w, r, err)
 		return
 	}
 
-	fmt.Fprintf(w, string(b))
+	setSessionCookie(w, r, token, time.Now().Add(sessionDuration))
+}
+
+func postAPILogout(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err == nil {
+		err = database.DeleteSession(cookie.Value)
+		if _, ok := err.(database.InvalidTokenError); err != nil && !ok {
+			writeDatabaseError(w, r, err)
+			return
+		}
+	}
+
+	// an unknown or expired session is logged out already
+	clearSessionCookie(w, r)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                             ACCOUNT API FUNCTIONS                          */
+/* -------------------------------------------------------------------------- */
+
+// putAPIAccountPassword changes the password of the logged in user,
+// which ends all of their sessions, so a new one is started.
+// LoggedIn is false if that failed and the user has to log in again.
+func putAPIAccountPassword(w http.ResponseWriter, r *http.Request, u int32) {
+	var subm passwordChangeInputT
+
+	// parse json request body into temporary passwordChangeInput
+	if !decodeJSON(w, r, &subm) {
+		return
+	}
+
+	if len(subm.NewPassword) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "NewPassword is empty")
+		return
+	}
+
+	name, err := database.GetUsernameByID(u)
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	// the old password is throttled like a login, so a stolen session can't be used to guess it
+	checked, err := checkLogin(r, name, subm.OldPassword, time.Now())
+	if tooMany, ok := err.(tooManyAttemptsError); ok {
+		denyTooManyAttempts(w, r, tooMany)
+		return
+	}
+	if checked != u {
+		writeAPIError(w, http.StatusForbidden, codeWrongPassword, "wrong OldPassword")
+		return
+	}
+
+	err = database.SetUserPassword(u, subm.NewPassword)
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	token, err := database.CreateSession(u, sessionDuration)
+	if err != nil {
+		// the password was changed nevertheless, the user can log in again
+		log.Printf("/api/account/password: session creation failed with error '%s' for UserID %d", err.Error(), u)
+		clearSessionCookie(w, r)
+		writeJSON(w, r, struct{ LoggedIn bool }{false})
+		return
+	}
+	setSessionCookie(w, r, token, time.Now().Add(sessionDuration))
+	writeJSON(w, r, struct{ LoggedIn bool }{true})
+}
+
+// postAPIReset sets a new password using a password reset token and logs the user in
+func postAPIReset(w http.ResponseWriter, r *http.Request) {
+	var subm passwordResetInputT
+
+	// parse json request body into temporary passwordResetInput
+	if !decodeJSON(w, r, &subm) {
+		return
+	}
+
+	if len(subm.Password) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Password is empty")
+		return
+	}
+
+	userid, err := database.ResetPassword(subm.Token, subm.Password)
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	// an admin may have locked the account because of the forgotten password
+	if name, err := database.GetUsernameByID(userid); err == nil {
+		userThrottle.reset(strings.ToLower(name))
+	}
+
+	token, err := database.CreateSession(userid, sessionDuration)
+	if err != nil {
+		// the password was reset nevertheless, the user can log in normally
+		log.Printf("/api/reset: session creation failed with error '%s' for UserID %d", err.Error(), userid)
+	} else {
+		setSessionCookie(w, r, token, time.Now().Add(sessionDuration))
+	}
+
+	writeJSON(w, r, struct{ UserID int32 }{userid})
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           SUBMISSIONS API FUNCTIONS                        */
+/* -------------------------------------------------------------------------- */
+
+// getAPIMeSubmissions returns the quotes the user submitted, newest first,
+// with whether they are still pending, were confirmed or rejected
+func getAPIMeSubmissions(w http.ResponseWriter, r *http.Request, u int32) {
+	submissions, err := database.GetSubmissions(u)
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	teachers, err := database.GetTeachers()
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	teachersByID := make(map[int32]database.TeacherT, len(teachers))
+	for _, t := range teachers {
+		teachersByID[t.TeacherID] = t
+	}
+
+	apiSubmissions := make([]submissionT, len(submissions))
+	for i, s := range submissions {
+		var teacher interface{} = s.TeacherName
+		if t, ok := teachersByID[s.TeacherID]; ok {
+			teacher = t
+		}
+		apiSubmissions[i] = submissionT{State: s.State, Teacher: teacher, Context: s.Context, Text: s.Text, Unixtime: s.Unixtime, Reason: s.Reason, Note: s.Note}
+		if s.State == database.SubmissionConfirmed {
+			apiSubmissions[i].QuoteID = s.QuoteID
+		} else {
+			apiSubmissions[i].SubmissionID = s.QuoteID
+		}
+	}
+
+	writeJSON(w, r, apiSubmissions)
+}
+
+// putAPIMeSubmissionsID lets the user edit one of their submissions while it is pending
+func putAPIMeSubmissionsID(w http.ResponseWriter, r *http.Request, u int32) {
+	id, ok := pendingSubmissionID(w, r, u)
+	if !ok {
+		return
+	}
+
+	quote, ok := readQuoteInput(w, r)
+	if !ok {
+		return
+	}
+
+	quote.QuoteID = id
+
+	// Update UnverifiedQuote in database
+	err := database.UpdateUnverifiedQuote(quote)
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+	}
+}
+
+// deleteAPIMeSubmissionsID lets the user withdraw one of their submissions while it is pending
+func deleteAPIMeSubmissionsID(w http.ResponseWriter, r *http.Request, u int32) {
+	id, ok := pendingSubmissionID(w, r, u)
+	if !ok {
+		return
+	}
+
+	// Delete UnverifiedQuote from database
+	err := database.DeleteUnverifiedQuote(id)
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+	}
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              USERS API FUNCTIONS                           */
+/* -------------------------------------------------------------------------- */
+
+func getAPIUsers(w http.ResponseWriter, r *http.Request, u int32) {
+	users, err := database.GetUsers()
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, users)
+}
+
+func postAPIUsers(w http.ResponseWriter, r *http.Request, u int32) {
+	var subm userInputT
+
+	// parse json request body into temporary userInput
+	if !decodeJSON(w, r, &subm) {
+		return
+	}
+
+	if len(subm.Name) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Name is empty")
+		return
+	}
+
+	if len(subm.Password) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Password is empty")
+		return
+	}
+
+	if subm.Role == "" {
+		subm.Role = database.RoleUser
+	}
+
+	if !subm.Role.IsValid() {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid Role: %s", subm.Role)
+		return
+	}
+
+	userid, err := database.CreateUser(database.UserT{
+		Name:     subm.Name,
+		Password: subm.Password,
+		Role:     subm.Role,
+	})
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, struct{ UserID int32 }{userid})
+}
+
+func putAPIUsersID(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return
+	}
+
+	var subm userUpdateInputT
+
+	// parse json request body into temporary userUpdateInput
+	if !decodeJSON(w, r, &subm) {
+		return
+	}
+
+	// validate the whole input before changing anything
+	if subm.Name != nil && len(*subm.Name) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidName, "Name is empty")
+		return
+	}
+
+	if subm.Role != nil && !subm.Role.IsValid() {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid Role: %s", *subm.Role)
+		return
+	}
+
+	if subm.Password != nil && len(*subm.Password) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Password is empty")
+		return
+	}
+
+	// admins must not lock themselves out
+	if int32(id) == u && ((subm.Role != nil && *subm.Role != database.RoleAdmin) || (subm.Disabled != nil && *subm.Disabled)) {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "you cannot change your own role or disable yourself")
+		return
+	}
+
+	user, err := database.GetUserByID(int32(id))
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	if subm.Name != nil {
+		user.Name = *subm.Name
+	}
+	if subm.Role != nil {
+		user.Role = *subm.Role
+	}
+	if subm.Disabled != nil {
+		user.Disabled = *subm.Disabled
+	}
+
+	var password string
+	if subm.Password != nil {
+		password = *subm.Password
+	}
+
+	// all changes, including the password, are saved in one transaction
+	err = database.UpdateUser(user, password)
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+}
+
+func deleteAPIUsersID(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return
+	}
+
+	if int32(id) == u {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "you cannot delete yourself")
+		return
+	}
+
+	err = database.DeleteUser(int32(id))
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+	}
+}
+
+// postAPIUsersIDResetLink creates a one-time link for the user to set a new password
+func postAPIUsersIDResetLink(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return
+	}
+
+	token, err := database.CreatePasswordReset(int32(id), resetLinkDuration)
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, struct {
+		Token   string
+		Expires int64
+	}{token, time.Now().Add(resetLinkDuration).Unix()})
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           RATE LIMITS API FUNCTIONS                        */
+/* -------------------------------------------------------------------------- */
+
+func getAPIRateLimits(w http.ResponseWriter, r *http.Request, u int32) {
+	writeJSON(w, r, database.GetRateLimits())
+}
+
+func putAPIRateLimitsName(w http.ResponseWriter, r *http.Request, u int32) {
+	name := mux.Vars(r)["name"]
+
+	if _, ok := database.GetRateLimit(name); !ok {
+		writeAPIError(w, http.StatusNotFound, codeNotFound, "unknown rate limit: %s", name)
+		return
+	}
+
+	var subm rateLimitInputT
+
+	// parse json request body into temporary rateLimitInput
+	if !decodeJSON(w, r, &subm) {
+		return
+	}
+
+	if subm.Burst < 1 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Burst must be at least 1")
+		return
+	}
+
+	if subm.PerHour < 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "PerHour must not be negative")
+		return
+	}
+
+	err := database.SetRateLimit(database.RateLimitT{
+		Name:    name,
+		Burst:   subm.Burst,
+		PerHour: subm.PerHour,
+	})
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+	}
+}
+
+/* -------------------------------------------------------------------------- */
+/*                            LOCKOUTS API FUNCTIONS                          */
+/* -------------------------------------------------------------------------- */
+
+func getAPILockouts(w http.ResponseWriter, r *http.Request, u int32) {
+	writeJSON(w, r, getLockouts())
+}
+
+// deleteAPILockouts unlocks the user name given by ?user= or the IP address given by ?ip=
+func deleteAPILockouts(w http.ResponseWriter, r *http.Request, u int32) {
+	query := r.URL.Query()
+
+	var found bool
+	switch {
+	case query.Get("user") != "":
+		found = userThrottle.reset(strings.ToLower(query.Get("user")))
+	case query.Get("ip") != "":
+		found = ipThrottle.reset(query.Get("ip"))
+	default:
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "neither user nor ip given")
+		return
+	}
+
+	if !found {
+		writeAPIError(w, http.StatusNotFound, codeNotFound, "no failed login attempts recorded")
+	}
+}
+
+/* -------------------------------------------------------------------------- */
+/*                             INVITES API FUNCTIONS                          */
+/* -------------------------------------------------------------------------- */
+
+func getAPIInvites(w http.ResponseWriter, r *http.Request, u int32) {
+	invites, err := database.GetInvites()
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, invites)
+}
+
+func postAPIInvites(w http.ResponseWriter, r *http.Request, u int32) {
+	var subm inviteInputT
+
+	// parse json request body into temporary inviteInput
+	if !decodeJSON(w, r, &subm) {
+		return
+	}
+
+	if subm.MaxUses < 1 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "MaxUses must be at least 1")
+		return
+	}
+
+	if subm.Expires <= time.Now().Unix() {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Expires is in the past")
+		return
+	}
+
+	if subm.Role == "" {
+		subm.Role = database.RoleUser
+	}
+
+	if !subm.Role.IsValid() {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid Role: %s", subm.Role)
+		return
+	}
+
+	inviteid, code, err := database.CreateInvite(database.InviteT{
+		MaxUses: subm.MaxUses,
+		Expires: subm.Expires,
+		Role:    subm.Role,
+	})
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, struct {
+		InviteID int32
+		Code     string
+	}{inviteid, code})
+}
+
+func deleteAPIInvitesID(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return
+	}
+
+	err = database.DeleteInvite(int32(id))
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+	}
+}
+
+// postAPIRegister creates a user from an invite code and logs them in
+func postAPIRegister(w http.ResponseWriter, r *http.Request) {
+	var subm registerInputT
+
+	// parse json request body into temporary registerInput
+	if !decodeJSON(w, r, &subm) {
+		return
+	}
+
+	if len(subm.Name) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Name is empty")
+		return
+	}
+
+	if len(subm.Password) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Password is empty")
+		return
+	}
+
+	userid, err := database.RegisterUser(subm.Code, subm.Name, subm.Password)
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	token, err := database.CreateSession(userid, sessionDuration)
+	if err != nil {
+		// the user exists nevertheless and can log in normally
+		log.Printf("/api/register: session creation failed with error '%s' for UserID %d", err.Error(), userid)
+	} else {
+		setSessionCookie(w, r, token, time.Now().Add(sessionDuration))
+	}
+
+	writeJSON(w, r, struct{ UserID int32 }{userid})
+}
+
+/* -------------------------------------------------------------------------- */
+/*                             TOKENS API FUNCTIONS                           */
+/* -------------------------------------------------------------------------- */
+
+func getAPITokens(w http.ResponseWriter, r *http.Request, u int32) {
+	tokens, err := database.GetTokens(u)
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, tokens)
+}
+
+func postAPITokens(w http.ResponseWriter, r *http.Request, u int32) {
+	var subm tokenInputT
+
+	// parse json request body into temporary tokenInput
+	if !decodeJSON(w, r, &subm) {
+		return
+	}
+
+	if len(subm.Name) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Name is empty")
+		return
+	}
+
+	// 0 means the token never expires
+	if subm.Expires != 0 && subm.Expires <= time.Now().Unix() {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Expires is in the past")
+		return
+	}
+
+	if subm.Scope == "" {
+		subm.Scope = database.ScopeAll
+	}
+
+	if !subm.Scope.IsValid() {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid Scope: %s", subm.Scope)
+		return
+	}
+
+	tokenid, token, err := database.CreateToken(database.TokenT{
+		UserID:  u,
+		Name:    subm.Name,
+		Scope:   subm.Scope,
+		Expires: subm.Expires,
+	})
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, struct {
+		TokenID int32
+		Token   string
+	}{tokenid, token})
+}
+
+func deleteAPITokensID(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return
+	}
+
+	// users can only revoke their own tokens
+	err = database.DeleteToken(u, int32(id))
+
+	if err != nil {
+		writeDatabaseError(w, r, err)
+	}
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              HELPER FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+// decodeJSON parses the JSON request body into v. If the body can't be read
+// completely or isn't parsable, it answers with an ErrorT and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	bytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, codeUnreadableBody, "reading the request body failed")
+		return false
+	}
+
+	err = json.Unmarshal(bytes, v)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidJSON, "unparsable JSON")
+		return false
+	}
+	return true
+}
+
+// toAPIQuotes embeds the teachers into the quotes
+//
+// Possible returned error types: generic
+func toAPIQuotes(quotes []database.QuoteT) ([]quoteT, error) {
+	teachers, err := database.GetTeachers()
+	if err != nil {
+		return nil, err
+	}
+
+	teachersByID := make(map[int32]database.TeacherT, len(teachers))
+	for _, t := range teachers {
+		teachersByID[t.TeacherID] = t
+	}
+
+	apiQuotes := make([]quoteT, len(quotes))
+	for i, q := range quotes {
+		apiQuotes[i] = quoteT{q.QuoteID, teachersByID[q.TeacherID], q.Context, q.Text, q.Unixtime, q.Stats, q.MyVote}
+	}
+	return apiQuotes, nil
+}
+
+// quotesPageLink returns the URL of the request with ?page= replaced by page
+func quotesPageLink(r *http.Request, page int) string {
+	query := r.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// writeSimilarQuotes answers with the quotes most similar to text, or 204 No Content if none match.
+// How many quotes are returned at most and how well they have to match
+// is given by ?limit= and ?threshold= (see database.GetMaxNQuotesByStringAbove).
+func writeSimilarQuotes(w http.ResponseWriter, r *http.Request, u int32, text string) {
+	query := r.URL.Query()
+
+	limit := defaultSimilarLimit
+	if query.Get("limit") != "" {
+		var err error
+		limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil || limit < 1 || limit > maxSimilarLimit {
+			writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid limit: must be between 1 and %d", maxSimilarLimit)
+			return
+		}
+	}
+
+	var threshold float64
+	if query.Get("threshold") != "" {
+		var err error
+		threshold, err = strconv.ParseFloat(query.Get("threshold"), 32)
+		if err != nil || threshold < 0 {
+			writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid threshold: must be a number of at least 0")
+			return
+		}
+	}
+
+	quotes, err := database.GetMaxNQuotesByStringAbove(limit, float32(threshold), text)
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	if len(quotes) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	err = database.AddUserDataToQuotes(quotes, u)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	apiQuotes, err := toAPIQuotes(quotes)
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	similarQuotes := make([]similarQuoteT, len(quotes))
+	for i := range quotes {
+		similarQuotes[i] = similarQuoteT{apiQuotes[i], quotes[i].Match}
+	}
+
+	writeJSON(w, r, struct{ Quotes []similarQuoteT }{similarQuotes})
+}
+
+// readQuoteInput parses the QuoteInputT in the request body into an unverified quote,
+// only TeacherID, TeacherName, Context and Text are set.
+// If it is invalid, an ErrorT is written and false is returned.
+func readQuoteInput(w http.ResponseWriter, r *http.Request) (database.UnverifiedQuoteT, bool) {
+	var subm quoteInputT
+	var quote database.UnverifiedQuoteT
+
+	// parse json request body into temporary QuoteInput
+	if !decodeJSON(w, r, &subm) {
+		return quote, false
+	}
+
+	// Check validity of temporary QuoteInput and
+	// copy content into UnverifiedQuote
+
+	switch subm.Teacher.(type) {
+	case float64:
+		if int32(subm.Teacher.(float64)) <= 0 {
+			writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid TeacherID: 0")
+			return quote, false
+		}
+		quote.TeacherID = int32(subm.Teacher.(float64))
+		quote.TeacherName = ""
+	case string:
+		quote.TeacherID = 0
+		quote.TeacherName = subm.Teacher.(string)
+	default:
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "invalid TeacherID: its type is neither string nor int")
+		return quote, false
+	}
+
+	if len(subm.Text) == 0 {
+		writeAPIError(w, http.StatusBadRequest, codeInvalidInput, "Text is empty")
+		return quote, false
+	}
+
+	quote.Context = subm.Context
+	quote.Text = subm.Text
+	return quote, true
+}
+
+// pendingSubmissionID returns the in-url id if it is the QuoteID of an unverified quote
+// submitted by the user, otherwise an ErrorT is written and false is returned.
+// Other users' submissions are treated as unknown.
+func pendingSubmissionID(w http.ResponseWriter, r *http.Request, u int32) (int32, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happend, because this handler is only called if
+		// uri pattern is matched, see web.go
+		writeAPIError(w, http.StatusInternalServerError, codeInternalError, "cannot convert in-url id to int")
+		return 0, false
+	}
+
+	quote, err := database.GetUnverifiedQuoteByID(int32(id))
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return 0, false
+	}
+
+	// answered like an unknown QuoteID, see databaseError
+	if quote.UserID != u {
+		writeAPIError(w, http.StatusNotFound, codeQuoteNotFound, "unknown QuoteID")
+		return 0, false
+	}
+
+	return int32(id), true
 }
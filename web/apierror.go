@@ -0,0 +1,185 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"quote_gallery/database"
+	"strings"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// apiErrorT is the body of every failed /api request, ErrorT in docs/apispec.tinyspec
+// Code     a stable, machine-readable identifier of the kind of error, see the code constants
+// Message  a human-readable description, which may change at any time
+type apiErrorT struct {
+	Code    string `json:"code"`
+	Message string `json:"error"`
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                  CONSTANTS                                 */
+/* -------------------------------------------------------------------------- */
+
+// The error codes, clients may rely on them not to change
+const (
+	// 400 Bad Request
+	codeUnreadableBody = "unreadable_body"
+	codeInvalidJSON    = "invalid_json"
+	codeInvalidInput   = "invalid_input"
+	codeInvalidName    = "invalid_name"
+
+	// 401 Unauthorized
+	codeUnauthorized  = "unauthorized"
+	codeWrongPassword = "wrong_password"
+
+	// 403 Forbidden
+	codeForbidden    = "forbidden"
+	codeInvalidCSRF  = "invalid_csrf_token"
+	codeInvalidToken = "invalid_token"
+
+	// 404 Not Found
+	codeNotFound        = "not_found"
+	codeQuoteNotFound   = "quote_not_found"
+	codeTeacherNotFound = "teacher_not_found"
+	codeUserNotFound    = "user_not_found"
+	codeInviteNotFound  = "invite_not_found"
+	codeTokenNotFound   = "token_not_found"
+
+	// 409 Conflict
+	codeTeacherInUse = "teacher_in_use"
+	codeBulkFailed   = "bulk_failed"
+
+	// 429 Too Many Requests
+	codeTooManyAttempts = "too_many_login_attempts"
+	codeRateLimited     = "rate_limited"
+
+	// 500 Internal Server Error
+	codeDatabaseError = "database_error"
+	codeInternalError = "internal_error"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                               ERROR RESPONSES                              */
+/* -------------------------------------------------------------------------- */
+
+// writeAPIError answers with the given status and an ErrorT JSON body
+func writeAPIError(w http.ResponseWriter, status int, code string, format string, a ...interface{}) {
+	b, err := json.Marshal(apiErrorT{code, fmt.Sprintf(format, a...)})
+	if err != nil {
+		// can't happen, apiErrorT only consists of strings
+		b = []byte(`{"code":"internal_error","error":"marshalling to json failed"}`)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
+// writeError answers like writeAPIError for /api routes and with plain text for pages.
+// It is used by the handlers wrapping both, e.g. the auth wrappers.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code string, format string, a ...interface{}) {
+	if isAPIRequest(r) {
+		writeAPIError(w, status, code, format, a...)
+		return
+	}
+
+	w.WriteHeader(status)
+	fmt.Fprintf(w, format+"\n", a...)
+}
+
+// databaseError maps an error returned by the database package to its status and ErrorT.
+// This is the only place where database errors get their code, so every route answers
+// the same error type with the same code. Errors of other types are internal errors.
+func databaseError(err error) (int, apiErrorT) {
+	switch err.(type) {
+	case database.InvalidQuoteIDError:
+		return http.StatusNotFound, apiErrorT{codeQuoteNotFound, "unknown QuoteID"}
+	case database.InvalidTeacherIDError:
+		return http.StatusNotFound, apiErrorT{codeTeacherNotFound, "unknown TeacherID"}
+	case database.InvalidUserIDError:
+		return http.StatusNotFound, apiErrorT{codeUserNotFound, "unknown UserID"}
+	case database.InvalidInviteIDError:
+		return http.StatusNotFound, apiErrorT{codeInviteNotFound, "unknown InviteID"}
+	case database.InvalidTokenIDError:
+		return http.StatusNotFound, apiErrorT{codeTokenNotFound, "unknown TokenID"}
+	case database.TeacherInUseError:
+		return http.StatusConflict, apiErrorT{codeTeacherInUse, "the teacher still has (unverified) quotes, delete them with ?cascade=true"}
+	case database.InvalidTokenError:
+		return http.StatusForbidden, apiErrorT{codeInvalidToken, "invalid, expired or used up invite code or reset link"}
+	case database.InvalidUserNameError:
+		return http.StatusBadRequest, apiErrorT{codeInvalidName, "Name is empty or already taken"}
+	case database.InvalidPasswordError:
+		return http.StatusBadRequest, apiErrorT{codeInvalidInput, "the password is longer than 72 bytes"}
+	case database.DBError:
+		return http.StatusInternalServerError, apiErrorT{codeDatabaseError, "database error"}
+	default:
+		return http.StatusInternalServerError, apiErrorT{codeInternalError, "internal server error"}
+	}
+}
+
+// writeDatabaseError answers with the status and ErrorT databaseError maps err to.
+// Internal errors are logged together with the route, but their details aren't handed to the client.
+func writeDatabaseError(w http.ResponseWriter, r *http.Request, err error) {
+	status, apiErr := databaseError(err)
+	writeAPIError(w, status, apiErr.Code, "%s", apiErr.Message)
+	logDatabaseError(r, status, err)
+}
+
+// writeDatabaseErrorStatus answers like writeDatabaseError, but with the given status and message.
+// It is for the few routes where the usual status would be misleading, the code stays the same.
+// Every override is listed in ERRORS of docs/apispec.tinyspec. Internal errors aren't overridden.
+func writeDatabaseErrorStatus(w http.ResponseWriter, r *http.Request, err error, status int, message string) {
+	mapped, apiErr := databaseError(err)
+	if mapped == http.StatusInternalServerError {
+		writeDatabaseError(w, r, err)
+		return
+	}
+	writeAPIError(w, status, apiErr.Code, "%s", message)
+}
+
+// logDatabaseError logs errors answered with 500 Internal Server Error together with the route
+func logDatabaseError(r *http.Request, status int, err error) {
+	if status != http.StatusInternalServerError {
+		return
+	}
+	if _, ok := err.(database.DBError); ok {
+		log.Printf("%s %s: database error '%s'", r.Method, r.URL.Path, err.Error())
+		return
+	}
+	log.Printf("%s %s: internal error '%s'", r.Method, r.URL.Path, err.Error())
+}
+
+// writeInternalError answers with 500 Internal Server Error and logs err together with the route
+func writeInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	writeAPIError(w, http.StatusInternalServerError, codeInternalError, "internal server error")
+	log.Printf("%s %s: internal error '%s'", r.Method, r.URL.Path, err.Error())
+}
+
+// writeJSON answers with v marshalled to JSON
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	writeJSONStatus(w, r, http.StatusOK, v)
+}
+
+// writeJSONStatus answers with the given status and v marshalled to JSON,
+// it is used for errors carrying more than an ErrorT
+func writeJSONStatus(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
+// isAPIRequest checks if the request is directed at the API instead of a page
+func isAPIRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/")
+}
@@ -2,64 +2,214 @@ package web
 
 import (
 	"net/http"
+	"net/url"
 	"quote_gallery/database"
+	"strings"
+	"time"
 )
 
-// adminAuth handles admin authorization
-func adminAuth(handler func(w http.ResponseWriter, r *http.Request, u int32)) http.HandlerFunc {
+/* -------------------------------------------------------------------------- */
+/*                                  CONSTANTS                                 */
+/* -------------------------------------------------------------------------- */
+
+// sessionCookieName is the name of the cookie holding the session token
+const sessionCookieName = "session"
+
+// sessionDuration is how long a session stays valid after logging in
+const sessionDuration = 14 * 24 * time.Hour
+
+// resetLinkDuration is how long a password reset link stays valid after an admin created it
+const resetLinkDuration = 3 * 24 * time.Hour
+
+/* -------------------------------------------------------------------------- */
+/*                               AUTH WRAPPERS                                */
+/* -------------------------------------------------------------------------- */
+
+// permAuth handles authorization of users whose role has the given permission
+func permAuth(permission database.PermissionT, handler func(w http.ResponseWriter, r *http.Request, u int32)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		user, password, ok := r.BasicAuth()
-		if ok {
-			a := database.IsAdmin(user, password)
-			if a != 0 {
-				handler(w, r, a)
-				return
-			}
+		u, role, scope, err := authenticate(r)
+		if tooMany, ok := err.(tooManyAttemptsError); ok {
+			denyTooManyAttempts(w, r, tooMany)
+			return
+		}
+		if u != 0 && role.Can(permission) && scopePermits(scope, r) {
+			handler(w, r, u)
+			return
 		}
 		// no access granted
-		w.Header().Set("WWW-Authenticate", `Basic realm="Log in (admin)"`)
-		w.WriteHeader(401)
-		w.Write([]byte("You not unauthorized as admin.\n"))
+		if u != 0 {
+			writeError(w, r, http.StatusForbidden, codeForbidden, "You are not permitted to do this.")
+			return
+		}
+		denyAccess(w, r)
 	}
 }
 
+// adminAuth handles admin authorization
+func adminAuth(handler func(w http.ResponseWriter, r *http.Request, u int32)) http.HandlerFunc {
+	return permAuth(database.PermissionAdminister, handler)
+}
+
+// moderatorAuth handles moderator (or admin) authorization
+func moderatorAuth(handler func(w http.ResponseWriter, r *http.Request, u int32)) http.HandlerFunc {
+	return permAuth(database.PermissionModerate, handler)
+}
+
 // userAuth handles user authorization
 func userAuth(handler func(w http.ResponseWriter, r *http.Request, u int32)) http.HandlerFunc {
+	return permAuth(database.PermissionUse, handler)
+}
+
+// anyAuth handles user authorization, passes along the user's role
+func anyAuth(handler func(w http.ResponseWriter, r *http.Request, u int32, role database.RoleT)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		user, password, ok := r.BasicAuth()
-		if ok {
-			u := database.IsUser(user, password)
-			if u != 0 {
-				handler(w, r, u)
-				return
-			}
+		u, role, scope, err := authenticate(r)
+		if tooMany, ok := err.(tooManyAttemptsError); ok {
+			denyTooManyAttempts(w, r, tooMany)
+			return
+		}
+		if u != 0 && role.Can(database.PermissionUse) && scopePermits(scope, r) {
+			handler(w, r, u, role)
+			return
 		}
 		// no access granted
-		w.Header().Set("WWW-Authenticate", `Basic realm="Log in (user)"`)
-		w.WriteHeader(401)
-		w.Write([]byte("You are not authorized as user.\n"))
+		if u != 0 && !scopePermits(scope, r) {
+			writeError(w, r, http.StatusForbidden, codeForbidden, "Your API token is not permitted to do this.")
+			return
+		}
+		denyAccess(w, r)
 	}
 }
 
-// anyAuth handles user/admin authorization, passes along isAdmin bool
-func anyAuth(handler func(w http.ResponseWriter, r *http.Request, u int32, isAdmin bool)) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		user, password, ok := r.BasicAuth()
-		if ok {
-			a := database.IsAdmin(user, password)
-			if a != 0 {
-				handler(w, r, a, true)
-				return
-			}
-			u := database.IsUser(user, password)
-			if u != 0 {
-				handler(w, r, u, false)
-				return
-			}
+/* -------------------------------------------------------------------------- */
+/*                              HELPER FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+// authenticate returns the UserID of the user making the request or 0 if the request
+// is not authenticated, the role of that user and the scope of the API token used,
+// which is empty if the request wasn't authenticated by an API token.
+// A session cookie is checked first, scripted clients can use
+// an API token (Bearer Auth) or Basic Auth instead.
+//
+// Possible returned error types: tooManyAttemptsError (Basic Auth is throttled, see checkLogin)
+func authenticate(r *http.Request) (int32, database.RoleT, database.ScopeT, error) {
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		u := database.GetSessionUserID(cookie.Value)
+		if u != 0 {
+			return u, database.GetUserRole(u), "", nil
 		}
-		// no access granted
-		w.Header().Set("WWW-Authenticate", `Basic realm="Log in (user/admin)"`)
-		w.WriteHeader(401)
-		w.Write([]byte("You are not authorized as user/admin.\n"))
 	}
+
+	if token, ok := bearerToken(r); ok {
+		u, scope := database.GetTokenUserID(token)
+		if u != 0 {
+			return u, database.GetUserRole(u), scope, nil
+		}
+	}
+
+	if name, password, ok := r.BasicAuth(); ok {
+		u, err := checkLogin(r, name, password, time.Now())
+		if err != nil {
+			return 0, "", "", err
+		}
+		if u != 0 {
+			return u, database.GetUserRole(u), "", nil
+		}
+	}
+
+	return 0, "", "", nil
+}
+
+// bearerToken returns the token sent in an "Authorization: Bearer <token>" header
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(auth[len(prefix):]), true
+}
+
+// scopePermits checks if a request authenticated by an API token with the given scope
+// may be handled. Requests authenticated otherwise (empty scope) are always permitted.
+// No API token may manage API tokens, so a leaked one can't be used to create more,
+// or change the password, which would start a session and take over the account.
+func scopePermits(scope database.ScopeT, r *http.Request) bool {
+	if scope == "" {
+		return true
+	}
+
+	if r.URL.Path == "/settings" || strings.HasPrefix(r.URL.Path, "/api/tokens") {
+		return false
+	}
+	if r.URL.Path == "/account" || strings.HasPrefix(r.URL.Path, "/api/account/") {
+		return false
+	}
+
+	switch scope {
+	case database.ScopeAll:
+		return true
+	case database.ScopeRead:
+		return isSafeMethod(r.Method)
+	case database.ScopeSubmit:
+		return r.Method == http.MethodPost && r.URL.Path == "/api/quotes/submit"
+	default:
+		return false
+	}
+}
+
+// denyAccess answers an unauthenticated request.
+// Browsers requesting a page are sent to the login page, which returns them
+// to the requested page afterwards. API clients get a 401 Unauthorized with an ErrorT.
+func denyAccess(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/api/") && r.Header.Get("Authorization") == "" {
+		http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="Log in"`)
+	writeError(w, r, http.StatusUnauthorized, codeUnauthorized, "You are not authorized.")
+}
+
+// setSessionCookie hands the session token to the client.
+// The cookie is not accessible to scripts and only sent via https if the request came via https.
+func setSessionCookie(w http.ResponseWriter, r *http.Request, token string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearSessionCookie makes the client delete its session cookie
+func clearSessionCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// isHTTPS checks if the client connected via https,
+// either directly or through a reverse proxy setting X-Forwarded-Proto
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+// safeRedirectTarget returns next if it is a path on this site, "/" otherwise.
+// This prevents the login page from being used to redirect to other sites.
+func safeRedirectTarget(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
 }
@@ -0,0 +1,125 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"html/template"
+	"log"
+	"net/http"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                  CONSTANTS                                 */
+/* -------------------------------------------------------------------------- */
+
+// csrfCookieName is the name of the cookie holding the CSRF token
+const csrfCookieName = "csrf"
+
+// csrfHeaderName is the request header which has to repeat the CSRF token,
+// axioshelpers.js sets it for every request
+const csrfHeaderName = "X-CSRF-Token"
+
+// csrfTokenLength is the length of the base64 encoded 32 byte token
+const csrfTokenLength = 43
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// csrfContextKey stores the CSRF token of a request in its context
+type csrfContextKey struct{}
+
+/* -------------------------------------------------------------------------- */
+/*                                 MIDDLEWARE                                 */
+/* -------------------------------------------------------------------------- */
+
+// csrfProtection is a mux middleware implementing the double submit pattern:
+// Every client gets a random token in a cookie, which pages also embed (see csrfFuncs).
+// State-changing requests have to repeat the token in the X-CSRF-Token header,
+// which other sites can neither read nor set.
+//
+// Scripted clients authenticating via the Authorization header are exempt,
+// as long as they send neither a session cookie nor an Origin header.
+// Browsers send an Origin header with every cross-site request,
+// so a forged request relying on remembered Basic Auth credentials is still checked.
+func csrfProtection(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := ""
+		if cookie, err := r.Cookie(csrfCookieName); err == nil && len(cookie.Value) == csrfTokenLength {
+			token = cookie.Value
+		}
+
+		if !isSafeMethod(r.Method) && !isScriptedRequest(r) {
+			sent := r.Header.Get(csrfHeaderName)
+			if token == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				writeError(w, r, http.StatusForbidden, codeInvalidCSRF, "invalid CSRF token, please reload the page")
+				return
+			}
+		}
+
+		if token == "" {
+			var err error
+			token, err = generateCSRFToken()
+			if err != nil {
+				writeError(w, r, http.StatusInternalServerError, codeInternalError, "internal server error")
+				log.Printf("%s %s: generating CSRF token failed with error '%s'", r.Method, r.URL.Path, err.Error())
+				return
+			}
+
+			http.SetCookie(w, &http.Cookie{
+				Name:     csrfCookieName,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   isHTTPS(r),
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfContextKey{}, token)))
+	})
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              HELPER FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+// csrfFuncs returns the template functions giving access to the request's CSRF token.
+// Pages embed it as <meta name="csrf-token" content="{{CSRFToken}}">.
+func csrfFuncs(r *http.Request) template.FuncMap {
+	token, _ := r.Context().Value(csrfContextKey{}).(string)
+	return template.FuncMap{
+		"CSRFToken": func() string { return token },
+	}
+}
+
+// isSafeMethod checks if requests with the given method don't change any state
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// isScriptedRequest checks if a request carries its credentials explicitly
+// instead of relying on anything a browser adds on its own, see csrfProtection
+func isScriptedRequest(r *http.Request) bool {
+	if r.Header.Get("Authorization") == "" || r.Header.Get("Origin") != "" {
+		return false
+	}
+	_, err := r.Cookie(sessionCookieName)
+	return err == http.ErrNoCookie
+}
+
+// generateCSRFToken returns a random, url safe token with 256 bits of entropy
+func generateCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -18,7 +18,21 @@ import (
 
 const quotesPerPage = 15
 
-func pageRoot(w http.ResponseWriter, r *http.Request, userID int32, isAdmin bool) {
+// how many likely duplicates the admin page shows next to each unverified quote
+const adminSimilarQuotes = 3
+
+// how many confirmed quotes /admin/quotes shows per page
+const adminQuotesPerPage = 50
+
+// reasonLabels are the texts shown for the reasons of rejecting a quote
+var reasonLabels = map[database.ReasonT]string{
+	database.ReasonDuplicate: "Doppelt eingesendet",
+	database.ReasonOffensive: "Beleidigend oder bloßstellend",
+	database.ReasonUnclear:   "Unverständlich",
+	database.ReasonOther:     "Sonstiges",
+}
+
+func pageRoot(w http.ResponseWriter, r *http.Request, userID int32, role database.RoleT) {
 	if r.URL.Path != "/" {
 		w.WriteHeader(404)
 		fmt.Fprint(w, "404 Not Found")
@@ -89,13 +103,14 @@ func pageRoot(w http.ResponseWriter, r *http.Request, userID int32, isAdmin bool
 		Current	int
 		Next	int
 		Last	int
-		IsAdmin bool
+		CanModerate bool
 		SortingOrder [6]string
 		SortingMap map[string]database.IndexHandler
 		CurrentSorting string
-	}{quotes, previousPage, currentPage, nextPage, lastPage, isAdmin, database.IndexHandlerOrder, database.IndexHandlers, indexHandlerKey}
+		Single bool
+	}{quotes, previousPage, currentPage, nextPage, lastPage, role.Can(database.PermissionModerate), database.IndexHandlerOrder, database.IndexHandlers, indexHandlerKey, false}
 
-	tmpl := template.Must(template.New("quotes.html").Funcs(template.FuncMap{
+	tmpl := template.Must(template.New("quotes.html").Funcs(csrfFuncs(r)).Funcs(template.FuncMap{
 		"inc": func (i int) int { return i+1 },
 		"div": func (a, b int32) string { return fmt.Sprintf("%.3f", float32(a)/float32(b)) },
 		"GetTeacherByID": database.GetTeacherByID,
@@ -103,6 +118,156 @@ func pageRoot(w http.ResponseWriter, r *http.Request, userID int32, isAdmin bool
 	tmpl.Execute(w, data)
 }
 
+// pageQuotesID shows a single quote, e.g. linked from a user's submissions
+func pageQuotesID(w http.ResponseWriter, r *http.Request, userID int32, role database.RoleT) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happen as pageQuotesID is only called if
+		// uri pattern is matched, see web.go
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "internal server error")
+		return
+	}
+
+	quote, err := database.GetQuoteByID(int32(id))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "failed to get quote #%v: %v", id, err)
+		return
+	}
+
+	quotes := []database.QuoteT{quote}
+	err = database.AddUserDataToQuotes(quotes, userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, err.Error())
+		return
+	}
+
+	data := struct {
+		Quotes	[]database.QuoteT
+		Prev	int
+		Current	int
+		Next	int
+		Last	int
+		CanModerate bool
+		SortingOrder [6]string
+		SortingMap map[string]database.IndexHandler
+		CurrentSorting string
+		Single bool
+	}{quotes, -1, 0, -1, 0, role.Can(database.PermissionModerate), database.IndexHandlerOrder, database.IndexHandlers, database.DefaultIndexHandlerName, true}
+
+	tmpl := template.Must(template.New("quotes.html").Funcs(csrfFuncs(r)).Funcs(template.FuncMap{
+		"inc": func (i int) int { return i+1 },
+		"div": func (a, b int32) string { return fmt.Sprintf("%.3f", float32(a)/float32(b)) },
+		"GetTeacherByID": database.GetTeacherByID,
+	}).ParseFiles("pages/quotes.html"))
+	tmpl.Execute(w, data)
+}
+
+func pageLogin(w http.ResponseWriter, r *http.Request) {
+	next := safeRedirectTarget(r.URL.Query().Get("next"))
+
+	// already logged in, e.g. by going back in the browser history
+	if u, _, _, _ := authenticate(r); u != 0 {
+		http.Redirect(w, r, next, http.StatusSeeOther)
+		return
+	}
+
+	tmpl := template.Must(template.New("login.html").Funcs(csrfFuncs(r)).ParseFiles("pages/login.html"))
+	tmpl.Execute(w, next)
+}
+
+func pageRegister(w http.ResponseWriter, r *http.Request) {
+	if u, _, _, _ := authenticate(r); u != 0 {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	tmpl := template.Must(template.New("register.html").Funcs(csrfFuncs(r)).ParseFiles("pages/register.html"))
+	tmpl.Execute(w, r.URL.Query().Get("code"))
+}
+
+func pageReset(w http.ResponseWriter, r *http.Request) {
+	tmpl := template.Must(template.New("reset.html").Funcs(csrfFuncs(r)).ParseFiles("pages/reset.html"))
+	tmpl.Execute(w, r.URL.Query().Get("token"))
+}
+
+func pageAccount(w http.ResponseWriter, r *http.Request, u int32) {
+	user, err := database.GetUserByID(u)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "failed to get user: %v", err)
+		return
+	}
+
+	tmpl := template.Must(template.New("account.html").Funcs(csrfFuncs(r)).ParseFiles("pages/account.html"))
+	tmpl.Execute(w, user)
+}
+
+// pageMine shows the quotes the user submitted and what became of them
+func pageMine(w http.ResponseWriter, r *http.Request, u int32) {
+	submissions, err := database.GetSubmissions(u)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "failed to get submissions: %v", err)
+		return
+	}
+
+	tmpl := template.Must(template.New("mine.html").Funcs(csrfFuncs(r)).Funcs(template.FuncMap{
+		"GetTeacherByID": database.GetTeacherByID,
+		"FormatUnixtime": func(utime int64) string {
+			return time.Unix(utime, 0).Format("2.1.2006 15:04")
+		},
+		"ReasonLabel": func(reason database.ReasonT) string {
+			return reasonLabels[reason]
+		},
+	}).ParseFiles("pages/mine.html"))
+	tmpl.Execute(w, submissions)
+}
+
+// pageMineIDEdit lets the user edit one of their submissions while it is pending
+func pageMineIDEdit(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happen as pageMineIDEdit is only called if
+		// uri pattern is matched, see web.go
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "internal server error")
+		return
+	}
+
+	// other users' submissions are treated as unknown
+	quote, err := database.GetUnverifiedQuoteByID(int32(id))
+	if err != nil || quote.UserID != u {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "no pending submission #%v", id)
+		return
+	}
+
+	teachers, err := database.GetTeachers()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "failed to get teachers: %v", err)
+		return
+	}
+
+	sort.Slice(teachers, func(i, j int) bool { return teachers[i].Name < teachers[j].Name })
+
+	editdata := struct {
+		Quote database.UnverifiedQuoteT
+		Teachers []database.TeacherT
+		API string
+	} {
+		quote,
+		teachers,
+		fmt.Sprintf("/api/me/submissions/%d", quote.QuoteID),
+	}
+
+	tmpl := template.Must(template.New("edit-unverifiedquote.html").Funcs(csrfFuncs(r)).ParseFiles("pages/edit-unverifiedquote.html"))
+	tmpl.Execute(w, editdata)
+}
+
 func pageAdmin(w http.ResponseWriter, r *http.Request, u int32) {
 	quotes, err := database.GetUnverifiedQuotes()
 	if err != nil {
@@ -128,25 +293,76 @@ func pageAdmin(w http.ResponseWriter, r *http.Request, u int32) {
 
 	_, showusers := r.URL.Query()["showusers"]
 
+	// moderators only get to see the unverified quotes
+	canAdminister := database.GetUserRole(u).Can(database.PermissionAdminister)
+
+	var users []database.UserInfoT
+	var invites []database.InviteT
+	if canAdminister {
+		users, err = database.GetUsers()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "failed to get users: %v", err)
+			return
+		}
+
+		invites, err = database.GetInvites()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "failed to get invites: %v", err)
+			return
+		}
+	}
+
 
 	pagedata := struct {
 		Quotes []database.UnverifiedQuoteT
 		Teachers []database.TeacherT
 		SortedTeachers []database.TeacherT
 		ShowUsers bool
+		Users []database.UserInfoT
+		MyUserID int32
+		Invites []database.InviteT
+		CanAdminister bool
+		Roles []database.RoleT
+		Lockouts []lockoutT
+		RateLimits []database.RateLimitT
+		Reasons []database.ReasonT
 	} {
 		quotes,
 		teachers,
 		sortedteachers,
 		showusers,
+		users,
+		u,
+		invites,
+		canAdminister,
+		database.Roles(),
+		nil,
+		nil,
+		database.Reasons(),
+	}
+
+	if canAdminister {
+		pagedata.Lockouts = getLockouts()
+		pagedata.RateLimits = database.GetRateLimits()
 	}
 
-	tmpl := template.Must(template.New("admin.html").Funcs(template.FuncMap{
+	tmpl := template.Must(template.New("admin.html").Funcs(csrfFuncs(r)).Funcs(template.FuncMap{
 		"GetTeacherByID": database.GetTeacherByID,
 		"GetUsernameByID": database.GetUsernameByID,
+		"GetSimilarQuotes": func(text string) ([]database.QuoteT, error) {
+			return database.GetMaxNQuotesByString(adminSimilarQuotes, text)
+		},
 		"FormatUnixtime": func(utime int64) string {
 			return time.Unix(utime, 0).Format("2.1.2006 15:04")
 		},
+		"FormatMatch": func(match float32) string {
+			return fmt.Sprintf("%.2f", match)
+		},
+		"ReasonLabel": func(reason database.ReasonT) string {
+			return reasonLabels[reason]
+		},
 	}).ParseFiles("pages/admin.html"))
 
 	err = tmpl.Execute(w, pagedata)
@@ -155,6 +371,30 @@ func pageAdmin(w http.ResponseWriter, r *http.Request, u int32) {
 	}
 }
 
+func pageSettings(w http.ResponseWriter, r *http.Request, u int32) {
+	tokens, err := database.GetTokens(u)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "failed to get tokens: %v", err)
+		return
+	}
+
+	pagedata := struct {
+		Tokens []database.TokenT
+		Scopes []database.ScopeT
+	} {
+		tokens,
+		database.Scopes(),
+	}
+
+	tmpl := template.Must(template.New("settings.html").Funcs(csrfFuncs(r)).Funcs(template.FuncMap{
+		"FormatUnixtime": func(utime int64) string {
+			return time.Unix(utime, 0).Format("2.1.2006 15:04")
+		},
+	}).ParseFiles("pages/settings.html"))
+	tmpl.Execute(w, pagedata)
+}
+
 func pageAdminUnverifiedQuotesIDEdit(w http.ResponseWriter, r *http.Request, u int32) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
@@ -182,12 +422,99 @@ func pageAdminUnverifiedQuotesIDEdit(w http.ResponseWriter, r *http.Request, u i
 	editdata := struct {
 		Quote database.UnverifiedQuoteT
 		Teachers []database.TeacherT
+		API string
+	} {
+		quote,
+		teachers,
+		fmt.Sprintf("/api/unverifiedquotes/%d", quote.QuoteID),
+	}
+
+	tmpl := template.Must(template.New("edit-unverifiedquote.html").Funcs(csrfFuncs(r)).ParseFiles("pages/edit-unverifiedquote.html"))
+	tmpl.Execute(w, editdata)
+}
+
+// pageAdminQuotes lists the confirmed quotes, latest first, to edit or delete them
+func pageAdminQuotes(w http.ResponseWriter, r *http.Request, u int32) {
+	nquotes := database.GetQuotesAmount()
+
+	page := 0
+	if pageQuery, ok := r.URL.Query()["page"]; ok {
+		p, err := strconv.Atoi(pageQuery[0])
+		if err == nil && p >= 0 && p*adminQuotesPerPage < nquotes {
+			page = p
+		}
+	}
+
+	quotes, err := database.GetNSortedQuotesFrom(adminQuotesPerPage, page*adminQuotesPerPage, database.IndexHandlers["timeDesc"].Function)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "failed to get quotes: %v", err)
+		return
+	}
+
+	nextPage := page + 1
+	if nquotes <= nextPage*adminQuotesPerPage {
+		nextPage = -1
+	}
+
+	pagedata := struct {
+		Quotes []database.QuoteT
+		Prev int
+		Next int
+	} {
+		quotes,
+		page - 1,
+		nextPage,
+	}
+
+	tmpl := template.Must(template.New("admin-quotes.html").Funcs(csrfFuncs(r)).Funcs(template.FuncMap{
+		"GetTeacherByID": database.GetTeacherByID,
+		"FormatUnixtime": func(utime int64) string {
+			return time.Unix(utime, 0).Format("2.1.2006 15:04")
+		},
+	}).ParseFiles("pages/admin-quotes.html"))
+
+	err = tmpl.Execute(w, pagedata)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func pageAdminQuotesIDEdit(w http.ResponseWriter, r *http.Request, u int32) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		// This should not happen as pageAdminQuotesIDEdit is only called if
+		// uri pattern is matched, see web.go
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "internal server error")
+		return
+	}
+
+	quote, err := database.GetQuoteByID(int32(id))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "failed to get quote #%v: %v", id, err)
+		return
+	}
+
+	teachers, err := database.GetTeachers()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "failed to get teachers: %v", err)
+		return
+	}
+
+	sort.Slice(teachers, func(i, j int) bool { return teachers[i].Name < teachers[j].Name })
+
+	editdata := struct {
+		Quote database.QuoteT
+		Teachers []database.TeacherT
 	} {
 		quote,
 		teachers,
 	}
 
-	tmpl := template.Must(template.ParseFiles("pages/edit-unverifiedquote.html"))
+	tmpl := template.Must(template.New("edit-quote.html").Funcs(csrfFuncs(r)).ParseFiles("pages/edit-quote.html"))
 	tmpl.Execute(w, editdata)
 }
 
@@ -208,7 +535,7 @@ func pageAdminTeachersIDEdit(w http.ResponseWriter, r *http.Request, u int32) {
 		return
 	}
 
-	tmpl := template.Must(template.ParseFiles("pages/edit-teacher.html"))
+	tmpl := template.Must(template.New("edit-teacher.html").Funcs(csrfFuncs(r)).ParseFiles("pages/edit-teacher.html"))
 	tmpl.Execute(w, teacher)
 }
 
@@ -234,7 +561,7 @@ func pageAdminTeachersAdd(w http.ResponseWriter, r *http.Request, u int32) {
 			t.Note = strings.Trim(noteWithoutParentheses, " ")
 		}
 	}
-	tmpl := template.Must(template.ParseFiles("pages/add-teacher.html"))
+	tmpl := template.Must(template.New("add-teacher.html").Funcs(csrfFuncs(r)).ParseFiles("pages/add-teacher.html"))
 	tmpl.Execute(w, t)
 }
 
@@ -246,7 +573,7 @@ func pageSubmit(w http.ResponseWriter, r *http.Request, u int32) {
 		return
 	}
 	sort.Slice(teachers, func(i, j int) bool { return teachers[i].Name < teachers[j].Name })
-	tmpl := template.Must(template.ParseFiles("pages/submit.html"))
+	tmpl := template.Must(template.New("submit.html").Funcs(csrfFuncs(r)).ParseFiles("pages/submit.html"))
 	tmpl.Execute(w, teachers)
 }
 
@@ -0,0 +1,100 @@
+package web
+
+import (
+	"math"
+	"net/http"
+	"quote_gallery/database"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// userLimiterT keeps a token bucket for every user, sized by the database.RateLimitT
+// with the given name. Every request takes one token, tokens are refilled continuously.
+// Changed limits apply to existing buckets right away.
+//
+// The buckets only live in memory, so restarting the server refills them.
+type userLimiterT struct {
+	mutex   sync.Mutex
+	name    string
+	buckets map[int32]*bucketT
+}
+
+// bucketT is the token bucket of one user
+type bucketT struct {
+	tokens float64
+	last   time.Time
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          GLOBAL PACKAGE VARIABLES                          */
+/* -------------------------------------------------------------------------- */
+
+var submissionLimiter = &userLimiterT{
+	name:    database.RateLimitSubmissions,
+	buckets: make(map[int32]*bucketT),
+}
+
+var voteLimiter = &userLimiterT{
+	name:    database.RateLimitVotes,
+	buckets: make(map[int32]*bucketT),
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                RATE LIMITING                               */
+/* -------------------------------------------------------------------------- */
+
+// rateLimited only lets each user call the handler as often as the limiter allows,
+// otherwise a 429 Too Many Requests with an ErrorT is returned.
+// It is meant to be wrapped in one of the auth wrappers, which determine the user.
+func rateLimited(limiter *userLimiterT, handler func(w http.ResponseWriter, r *http.Request, u int32)) func(w http.ResponseWriter, r *http.Request, u int32) {
+	return func(w http.ResponseWriter, r *http.Request, u int32) {
+		wait := limiter.take(u, time.Now())
+		if wait == 0 {
+			handler(w, r, u)
+			return
+		}
+
+		seconds := int(math.Ceil(wait.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		writeAPIError(w, http.StatusTooManyRequests, codeRateLimited, "rate limit for %s exceeded, try again in %d seconds", limiter.name, seconds)
+	}
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              HELPER FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+// take takes a token from the user's bucket and returns 0,
+// or how long to wait for the next token if the bucket is empty
+func (l *userLimiterT) take(u int32, now time.Time) time.Duration {
+	limit, ok := database.GetRateLimit(l.name)
+	if !ok || limit.PerHour == 0 {
+		return 0
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// new buckets start full
+	b, ok := l.buckets[u]
+	if !ok {
+		b = &bucketT{float64(limit.Burst), now}
+		l.buckets[u] = b
+	}
+
+	perSecond := float64(limit.PerHour) / 3600
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*perSecond)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+	}
+
+	b.tokens--
+	return 0
+}
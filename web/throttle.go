@@ -0,0 +1,260 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"quote_gallery/database"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 DEFINITIONS                                */
+/* -------------------------------------------------------------------------- */
+
+// loginThrottleT counts failed password checks per key (a user name or an IP address).
+// After freeAttempts failures, every further attempt has to wait twice as long as the
+// previous one, and after lockAfter failures the key is locked for loginLockDuration.
+// Counters are forgotten once there was no failure for loginForgetAfter.
+//
+// The counters only live in memory, so restarting the server unlocks everything.
+type loginThrottleT struct {
+	mutex    sync.Mutex
+	failures map[string]*loginFailuresT
+
+	freeAttempts int
+	lockAfter    int
+}
+
+// loginFailuresT are the failed attempts of one key
+type loginFailuresT struct {
+	Count int
+	Last  time.Time
+}
+
+// lockoutT describes a throttled key for the admin page
+// Kind      "user" or "ip"
+// Key       the (lowercased) user name or the IP address
+// Failures  the number of failed attempts
+// Until     the unixtime until which attempts are rejected
+// Locked    whether the key is locked instead of just slowed down
+type lockoutT struct {
+	Kind     string
+	Key      string
+	Failures int
+	Until    int64
+	Locked   bool
+}
+
+// tooManyAttemptsError is returned when a password check is rejected without checking it
+type tooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (err tooManyAttemptsError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry after %v", err.RetryAfter)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                  CONSTANTS                                 */
+/* -------------------------------------------------------------------------- */
+
+// the first delay after the free attempts, it doubles with every further failure
+const loginBaseDelay = time.Second
+
+// the longest delay between two attempts before a key is locked
+const loginMaxDelay = 5 * time.Minute
+
+// how long a key stays locked, unless an admin unlocks it
+const loginLockDuration = time.Hour
+
+// how long failures are remembered
+const loginForgetAfter = 24 * time.Hour
+
+/* -------------------------------------------------------------------------- */
+/*                          GLOBAL PACKAGE VARIABLES                          */
+/* -------------------------------------------------------------------------- */
+
+// userThrottle protects single accounts against guessing their password
+var userThrottle = &loginThrottleT{
+	failures:     make(map[string]*loginFailuresT),
+	freeAttempts: 3,
+	lockAfter:    10,
+}
+
+// ipThrottle slows down clients trying many accounts.
+// Its limits are higher, because a whole school might share one IP address.
+var ipThrottle = &loginThrottleT{
+	failures:     make(map[string]*loginFailuresT),
+	freeAttempts: 20,
+	lockAfter:    100,
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              LOGIN THROTTLING                              */
+/* -------------------------------------------------------------------------- */
+
+// checkLogin checks a user name and password using database.IsUser,
+// unless the user name or the client's IP address had too many failed attempts,
+// then a tooManyAttemptsError is returned without checking the password.
+// now is the time of the attempt, usually time.Now().
+func checkLogin(r *http.Request, name string, password string, now time.Time) (int32, error) {
+	ip := clientIP(r)
+	// names are compared ignoring case
+	key := strings.ToLower(name)
+
+	wait := userThrottle.wait(key, now)
+	if ipWait := ipThrottle.wait(ip, now); ipWait > wait {
+		wait = ipWait
+	}
+	if wait > 0 {
+		return 0, tooManyAttemptsError{wait}
+	}
+
+	// the attempt is counted as failed before checking the password, which takes a while,
+	// so that parallel requests can't get around the throttle
+	userThrottle.fail(key, now)
+	ipThrottle.fail(ip, now)
+
+	u := database.IsUser(name, password)
+	if u == 0 {
+		return 0, nil
+	}
+
+	// earlier failures of the IP address are not forgiven,
+	// a client could log into its own account in between
+	userThrottle.reset(key)
+	ipThrottle.forgive(ip)
+	return u, nil
+}
+
+// denyTooManyAttempts answers a request whose password wasn't checked, see checkLogin
+func denyTooManyAttempts(w http.ResponseWriter, r *http.Request, err tooManyAttemptsError) {
+	seconds := int(math.Ceil(err.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeError(w, r, http.StatusTooManyRequests, codeTooManyAttempts, "too many failed login attempts, try again in %d seconds", seconds)
+}
+
+// getLockouts returns all currently throttled user names and IP addresses
+func getLockouts() []lockoutT {
+	now := time.Now()
+	lockouts := append(userThrottle.lockouts("user", now), ipThrottle.lockouts("ip", now)...)
+	sort.Slice(lockouts, func(i, j int) bool { return lockouts[i].Until > lockouts[j].Until })
+	return lockouts
+}
+
+// clientIP returns the IP address of the client.
+// X-Forwarded-For is ignored, as any client could set it to evade the throttle.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              HELPER FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+// wait returns how long the key has to wait before its next attempt, 0 if it may try now
+func (t *loginThrottleT) wait(key string, now time.Time) time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	f, ok := t.failures[key]
+	if !ok {
+		return 0
+	}
+
+	wait := f.Last.Add(t.delay(f.Count)).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// fail counts a failed attempt of the key, forgetting old failures of all keys on the way
+func (t *loginThrottleT) fail(key string, now time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for k, f := range t.failures {
+		if now.Sub(f.Last) > loginForgetAfter {
+			delete(t.failures, k)
+		}
+	}
+
+	f, ok := t.failures[key]
+	if !ok {
+		f = &loginFailuresT{}
+		t.failures[key] = f
+	}
+	f.Count++
+	f.Last = now
+}
+
+// reset forgets the failures of the key, e.g. after a successful attempt or to unlock it.
+// It returns whether there were any.
+func (t *loginThrottleT) reset(key string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	_, ok := t.failures[key]
+	delete(t.failures, key)
+	return ok
+}
+
+// forgive takes back the last failed attempt of the key
+func (t *loginThrottleT) forgive(key string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	f, ok := t.failures[key]
+	if !ok {
+		return
+	}
+	f.Count--
+	if f.Count <= 0 {
+		delete(t.failures, key)
+	}
+}
+
+// lockouts returns all keys which currently have to wait
+func (t *loginThrottleT) lockouts(kind string, now time.Time) []lockoutT {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var lockouts []lockoutT
+	for key, f := range t.failures {
+		until := f.Last.Add(t.delay(f.Count))
+		if until.After(now) {
+			lockouts = append(lockouts, lockoutT{kind, key, f.Count, until.Unix(), f.Count >= t.lockAfter})
+		}
+	}
+	return lockouts
+}
+
+// delay returns how long to wait after the last of count failures
+func (t *loginThrottleT) delay(count int) time.Duration {
+	if count >= t.lockAfter {
+		return loginLockDuration
+	}
+	if count < t.freeAttempts {
+		return 0
+	}
+
+	delay := loginBaseDelay
+	for i := t.freeAttempts; i < count && delay < loginMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > loginMaxDelay {
+		return loginMaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,229 @@
+package web
+
+import (
+	"net/http/httptest"
+	"quote_gallery/database"
+	"testing"
+	"time"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                    TESTS                                   */
+/* -------------------------------------------------------------------------- */
+
+// TestLoginThrottleDelay checks that the delay doubles after the free attempts,
+// stops growing at loginMaxDelay and becomes loginLockDuration after lockAfter failures
+func TestLoginThrottleDelay(t *testing.T) {
+	throttle := newTestThrottle(3, 10)
+	slow := newTestThrottle(1, 100)
+
+	tests := []struct {
+		throttle *loginThrottleT
+		count    int
+		delay    time.Duration
+	}{
+		{throttle, 0, 0},
+		{throttle, 2, 0},
+		{throttle, 3, time.Second},
+		{throttle, 4, 2 * time.Second},
+		{throttle, 5, 4 * time.Second},
+		{throttle, 9, 64 * time.Second},
+		{throttle, 10, loginLockDuration},
+		{throttle, 11, loginLockDuration},
+		{slow, 1, time.Second},
+		{slow, 9, 256 * time.Second},
+		{slow, 10, loginMaxDelay},
+		{slow, 99, loginMaxDelay},
+		{slow, 100, loginLockDuration},
+	}
+
+	for _, test := range tests {
+		if delay := test.throttle.delay(test.count); delay != test.delay {
+			t.Errorf("delay(%d) with %d free attempts = %v, want %v", test.count, test.throttle.freeAttempts, delay, test.delay)
+		}
+	}
+}
+
+// TestLoginThrottleWait fails a key until it is locked, checking the wait after every failure
+func TestLoginThrottleWait(t *testing.T) {
+	throttle := newTestThrottle(3, 10)
+	now := time.Unix(1000000, 0)
+
+	for count := 1; count <= throttle.lockAfter; count++ {
+		throttle.fail("user", now)
+
+		delay := throttle.delay(count)
+		if wait := throttle.wait("user", now); wait != delay {
+			t.Errorf("wait after %d failures = %v, want %v", count, wait, delay)
+		}
+		if wait := throttle.wait("user", now.Add(delay/2)); wait != delay-delay/2 {
+			t.Errorf("wait %v after %d failures = %v, want %v", delay/2, count, wait, delay-delay/2)
+		}
+		if wait := throttle.wait("user", now.Add(delay)); wait != 0 {
+			t.Errorf("wait %v after %d failures = %v, want 0", delay, count, wait)
+		}
+		if wait := throttle.wait("other", now); wait != 0 {
+			t.Errorf("wait of another key after %d failures = %v, want 0", count, wait)
+		}
+
+		// the next attempt happens as soon as it is allowed
+		now = now.Add(delay)
+	}
+
+	// the key is locked now
+	lockedAt := now.Add(-loginLockDuration)
+	if wait := throttle.wait("user", lockedAt); wait != loginLockDuration {
+		t.Errorf("wait after %d failures = %v, want %v", throttle.lockAfter, wait, loginLockDuration)
+	}
+
+	lockouts := throttle.lockouts("user", lockedAt)
+	want := lockoutT{"user", "user", throttle.lockAfter, now.Unix(), true}
+	if len(lockouts) != 1 || lockouts[0] != want {
+		t.Errorf("lockouts = %v, want [%v]", lockouts, want)
+	}
+	if lockouts := throttle.lockouts("user", now); len(lockouts) != 0 {
+		t.Errorf("lockouts after the lock = %v, want none", lockouts)
+	}
+}
+
+// TestLoginThrottleForgive checks that forgive takes back one failure and reset all of them
+func TestLoginThrottleForgive(t *testing.T) {
+	throttle := newTestThrottle(1, 10)
+	now := time.Unix(1000000, 0)
+
+	throttle.fail("user", now)
+	throttle.fail("user", now)
+	throttle.forgive("user")
+	if wait := throttle.wait("user", now); wait != time.Second {
+		t.Errorf("wait after 2 failures and forgive = %v, want %v", wait, time.Second)
+	}
+
+	throttle.forgive("user")
+	if _, ok := throttle.failures["user"]; ok {
+		t.Errorf("failures of the key are kept after forgiving all of them")
+	}
+	throttle.forgive("user")
+
+	for i := 0; i < throttle.lockAfter; i++ {
+		throttle.fail("user", now)
+	}
+	if !throttle.reset("user") {
+		t.Errorf("reset of a locked key returned false")
+	}
+	if wait := throttle.wait("user", now); wait != 0 {
+		t.Errorf("wait after reset = %v, want 0", wait)
+	}
+	if throttle.reset("user") {
+		t.Errorf("reset of a key without failures returned true")
+	}
+}
+
+// TestLoginThrottleForget checks that failures are forgotten after loginForgetAfter
+func TestLoginThrottleForget(t *testing.T) {
+	throttle := newTestThrottle(3, 10)
+	now := time.Unix(1000000, 0)
+
+	for i := 0; i < throttle.lockAfter; i++ {
+		throttle.fail("old", now)
+	}
+	throttle.fail("kept", now.Add(time.Second))
+
+	// failures are only forgotten when another failure is counted
+	throttle.fail("new", now.Add(loginForgetAfter))
+	if _, ok := throttle.failures["old"]; !ok {
+		t.Errorf("failures are forgotten after exactly %v", loginForgetAfter)
+	}
+
+	throttle.fail("new", now.Add(loginForgetAfter+time.Second/2))
+	if _, ok := throttle.failures["old"]; ok {
+		t.Errorf("failures are still known after more than %v", loginForgetAfter)
+	}
+	if f, ok := throttle.failures["kept"]; !ok || f.Count != 1 {
+		t.Errorf("failures of a younger key = %v, want 1", f)
+	}
+
+	throttle.fail("old", now.Add(loginForgetAfter+time.Second))
+	if f := throttle.failures["old"]; f.Count != 1 {
+		t.Errorf("failures of a forgotten key after failing again = %d, want 1", f.Count)
+	}
+}
+
+// TestCheckLogin logs into the seeded memory store with wrong and right passwords
+func TestCheckLogin(t *testing.T) {
+	useTestDatabase(t)
+	userThrottle = newTestThrottle(3, 10)
+	ipThrottle = newTestThrottle(5, 100)
+
+	r := httptest.NewRequest("POST", "/api/login", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	now := time.Unix(1000000, 0)
+
+	for i := 0; i < userThrottle.freeAttempts; i++ {
+		if u, err := checkLogin(r, "User", "wrong", now); u != 0 || err != nil {
+			t.Fatalf("checkLogin %d with wrong password = %d, %v, want 0, nil", i+1, u, err)
+		}
+	}
+
+	// names are throttled ignoring case
+	_, err := checkLogin(r, "user", "user", now)
+	if err, ok := err.(tooManyAttemptsError); !ok || err.RetryAfter != time.Second {
+		t.Fatalf("checkLogin after %d failures returned %v, want to retry after 1s", userThrottle.freeAttempts, err)
+	}
+	if f := ipThrottle.failures["192.0.2.1"]; f.Count != userThrottle.freeAttempts {
+		t.Errorf("failures of the IP address = %d, want %d, rejected attempts aren't counted", f.Count, userThrottle.freeAttempts)
+	}
+
+	now = now.Add(time.Second)
+	u, err := checkLogin(r, "user", "user", now)
+	if u == 0 || err != nil {
+		t.Fatalf("checkLogin with right password after waiting = %d, %v, want a UserID", u, err)
+	}
+	if _, ok := userThrottle.failures["user"]; ok {
+		t.Errorf("failures of the user name are kept after logging in")
+	}
+	if f := ipThrottle.failures["192.0.2.1"]; f.Count != userThrottle.freeAttempts {
+		t.Errorf("failures of the IP address after logging in = %d, want %d", f.Count, userThrottle.freeAttempts)
+	}
+
+	// the IP address is throttled for other user names as well
+	for _, name := range []string{"admin", "moderator"} {
+		checkLogin(r, name, "wrong", now)
+	}
+	_, err = checkLogin(r, "unknown", "wrong", now)
+	if err, ok := err.(tooManyAttemptsError); !ok || err.RetryAfter != time.Second {
+		t.Errorf("checkLogin after %d failures of the IP address returned %v, want to retry after 1s", ipThrottle.freeAttempts, err)
+	}
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              HELPER FUNCTIONS                              */
+/* -------------------------------------------------------------------------- */
+
+func newTestThrottle(freeAttempts int, lockAfter int) *loginThrottleT {
+	return &loginThrottleT{
+		failures:     make(map[string]*loginFailuresT),
+		freeAttempts: freeAttempts,
+		lockAfter:    lockAfter,
+	}
+}
+
+// useTestDatabase connects to a memory store seeded with admin, moderator and user,
+// whose passwords are their names, and restores the throttles once the test has finished
+func useTestDatabase(t *testing.T) {
+	t.Setenv("DB_DRIVER", "memory")
+	t.Setenv("DB_SEED", "1")
+
+	err := database.Connect()
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { database.CloseAndClearCache() })
+
+	err = database.Initialize()
+	if err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	user, ip := userThrottle, ipThrottle
+	t.Cleanup(func() { userThrottle, ipThrottle = user, ip })
+}
@@ -10,6 +10,7 @@ import (
 //SetupRoutes configures which paths are handled by which functions
 func SetupRoutes() {
 	rt := mux.NewRouter()
+	rt.Use(csrfProtection)
 
 	rt.HandleFunc("/", anyAuth(pageRoot))
 
@@ -18,28 +19,90 @@ func SetupRoutes() {
 	rt.PathPrefix("/static/").Handler(http.StripPrefix("/static/", handlerFiles))
 
 	// pages
+	rt.HandleFunc("/login", pageLogin )
+	rt.HandleFunc("/register", pageRegister )
+	rt.HandleFunc("/reset", pageReset )
 	rt.HandleFunc("/submit", userAuth(pageSubmit) )
 	rt.HandleFunc("/suggestions", userAuth(pageSimilarQuotes) )
+	rt.HandleFunc("/settings", userAuth(pageSettings) )
+	rt.HandleFunc("/account", userAuth(pageAccount) )
+	rt.HandleFunc("/mine", userAuth(pageMine) )
+	rt.HandleFunc("/mine/{id:[0-9]+}/edit", userAuth(pageMineIDEdit) )
+	rt.HandleFunc("/quotes/{id:[0-9]+}", anyAuth(pageQuotesID) )
 
 	// admin pages
-	rt.HandleFunc("/admin", adminAuth(pageAdmin) )
-	rt.HandleFunc("/admin/unverifiedquotes/{id:[0-9]+}/edit", adminAuth(pageAdminUnverifiedQuotesIDEdit) )
+	rt.HandleFunc("/admin", moderatorAuth(pageAdmin) )
+	rt.HandleFunc("/admin/quotes", adminAuth(pageAdminQuotes) )
+	rt.HandleFunc("/admin/quotes/{id:[0-9]+}/edit", adminAuth(pageAdminQuotesIDEdit) )
+	rt.HandleFunc("/admin/unverifiedquotes/{id:[0-9]+}/edit", moderatorAuth(pageAdminUnverifiedQuotesIDEdit) )
 	rt.HandleFunc("/admin/teachers/{id:[0-9]+}/edit", adminAuth(pageAdminTeachersIDEdit) )
 	rt.HandleFunc("/admin/teachers/add", adminAuth(pageAdminTeachersAdd) )
 
+	// /api/login, /api/logout
+	rt.HandleFunc("/api/login", postAPILogin ).Methods("POST")
+	rt.HandleFunc("/api/logout", postAPILogout ).Methods("POST")
+	rt.HandleFunc("/api/register", postAPIRegister ).Methods("POST")
+	rt.HandleFunc("/api/reset", postAPIReset ).Methods("POST")
+
+	// /api/account
+	rt.HandleFunc("/api/account/password", userAuth(putAPIAccountPassword) ).Methods("PUT")
+
+	// /api/me
+	rt.HandleFunc("/api/me/submissions", userAuth(getAPIMeSubmissions) ).Methods("GET")
+	rt.HandleFunc("/api/me/submissions/{id:[0-9]+}", userAuth(putAPIMeSubmissionsID) ).Methods("PUT")
+	rt.HandleFunc("/api/me/submissions/{id:[0-9]+}", userAuth(deleteAPIMeSubmissionsID) ).Methods("DELETE")
+
 	// /api/quotes
-	rt.HandleFunc("/api/quotes/submit", userAuth(postAPIQuotesSubmit) ).Methods("POST")
-	rt.HandleFunc("/api/quotes/{id:[0-9]+}/vote/{val:[1-5]}", userAuth(putAPIQuotesIDVoteRating) ).Methods("PUT")
+	rt.HandleFunc("/api/quotes", userAuth(getAPIQuotes) ).Methods("GET")
+	rt.HandleFunc("/api/quotes/{id:[0-9]+}", userAuth(getAPIQuotesID) ).Methods("GET")
+	rt.HandleFunc("/api/quotes/{id:[0-9]+}", adminAuth(putAPIQuotesID) ).Methods("PUT")
+	rt.HandleFunc("/api/quotes/{id:[0-9]+}", adminAuth(deleteAPIQuotesID) ).Methods("DELETE")
+	rt.HandleFunc("/api/quotes/similar", userAuth(postAPIQuotesSimilar) ).Methods("POST")
+	rt.HandleFunc("/api/quotes/submit", userAuth(rateLimited(submissionLimiter, postAPIQuotesSubmit)) ).Methods("POST")
+	rt.HandleFunc("/api/quotes/{id:[0-9]+}/vote/{val:[1-5]}", userAuth(rateLimited(voteLimiter, putAPIQuotesIDVoteRating)) ).Methods("PUT")
+	rt.HandleFunc("/api/quotes/{id:[0-9]+}/vote", userAuth(rateLimited(voteLimiter, deleteAPIQuotesIDVote)) ).Methods("DELETE")
 
 	// /api/unverifiedquotes
-	rt.HandleFunc("/api/unverifiedquotes/{id:[0-9]+}", adminAuth(putAPIUnverifiedQuotesID) ).Methods("PUT")
-	rt.HandleFunc("/api/unverifiedquotes/{id:[0-9]+}", adminAuth(deleteAPIUnverifiedQuotesID) ).Methods("DELETE")
-	rt.HandleFunc("/api/unverifiedquotes/{id:[0-9]+}/confirm", adminAuth(putAPIUnverifiedQuotesIDConfirm) ).Methods("PUT")
-	rt.HandleFunc("/api/unverifiedquotes/{quoteid:[0-9]+}/assignteacher/{teacherid:[0-9]+}", adminAuth(putAPIUnverifiedQuotesIDAssignTeacherID)).Methods("PUT")
+	rt.HandleFunc("/api/unverifiedquotes/bulk", moderatorAuth(postAPIUnverifiedQuotesBulk) ).Methods("POST")
+	rt.HandleFunc("/api/unverifiedquotes/{id:[0-9]+}", moderatorAuth(putAPIUnverifiedQuotesID) ).Methods("PUT")
+	rt.HandleFunc("/api/unverifiedquotes/{id:[0-9]+}", moderatorAuth(deleteAPIUnverifiedQuotesID) ).Methods("DELETE")
+	rt.HandleFunc("/api/unverifiedquotes/{id:[0-9]+}/similar", moderatorAuth(getAPIUnverifiedQuotesIDSimilar) ).Methods("GET")
+	rt.HandleFunc("/api/unverifiedquotes/{id:[0-9]+}/confirm", moderatorAuth(putAPIUnverifiedQuotesIDConfirm) ).Methods("PUT")
+	rt.HandleFunc("/api/unverifiedquotes/{id:[0-9]+}/reject", moderatorAuth(putAPIUnverifiedQuotesIDReject) ).Methods("PUT")
+	rt.HandleFunc("/api/unverifiedquotes/{quoteid:[0-9]+}/assignteacher/{teacherid:[0-9]+}", moderatorAuth(putAPIUnverifiedQuotesIDAssignTeacherID)).Methods("PUT")
 
 	// /api/teachers
+	rt.HandleFunc("/api/teachers", userAuth(getAPITeachers) ).Methods("GET")
 	rt.HandleFunc("/api/teachers", adminAuth(postAPITeachers) ).Methods("POST")
+	rt.HandleFunc("/api/teachers/{id:[0-9]+}", userAuth(getAPITeachersID) ).Methods("GET")
 	rt.HandleFunc("/api/teachers/{id:[0-9]+}", adminAuth(putAPITeachersID) ).Methods("PUT")
+	rt.HandleFunc("/api/teachers/{id:[0-9]+}", adminAuth(deleteAPITeachersID) ).Methods("DELETE")
+	rt.HandleFunc("/api/teachers/{id:[0-9]+}/merge/{otherid:[0-9]+}", adminAuth(postAPITeachersIDMergeOtherID) ).Methods("POST")
+
+	// /api/users
+	rt.HandleFunc("/api/users", adminAuth(getAPIUsers) ).Methods("GET")
+	rt.HandleFunc("/api/users", adminAuth(postAPIUsers) ).Methods("POST")
+	rt.HandleFunc("/api/users/{id:[0-9]+}", adminAuth(putAPIUsersID) ).Methods("PUT")
+	rt.HandleFunc("/api/users/{id:[0-9]+}", adminAuth(deleteAPIUsersID) ).Methods("DELETE")
+	rt.HandleFunc("/api/users/{id:[0-9]+}/resetlink", adminAuth(postAPIUsersIDResetLink) ).Methods("POST")
+
+	// /api/invites
+	rt.HandleFunc("/api/invites", adminAuth(getAPIInvites) ).Methods("GET")
+	rt.HandleFunc("/api/invites", adminAuth(postAPIInvites) ).Methods("POST")
+	rt.HandleFunc("/api/invites/{id:[0-9]+}", adminAuth(deleteAPIInvitesID) ).Methods("DELETE")
+
+	// /api/tokens
+	rt.HandleFunc("/api/tokens", userAuth(getAPITokens) ).Methods("GET")
+	rt.HandleFunc("/api/tokens", userAuth(postAPITokens) ).Methods("POST")
+	rt.HandleFunc("/api/tokens/{id:[0-9]+}", userAuth(deleteAPITokensID) ).Methods("DELETE")
+
+	// /api/ratelimits
+	rt.HandleFunc("/api/ratelimits", adminAuth(getAPIRateLimits) ).Methods("GET")
+	rt.HandleFunc("/api/ratelimits/{name}", adminAuth(putAPIRateLimitsName) ).Methods("PUT")
+
+	// /api/lockouts
+	rt.HandleFunc("/api/lockouts", adminAuth(getAPILockouts) ).Methods("GET")
+	rt.HandleFunc("/api/lockouts", adminAuth(deleteAPILockouts) ).Methods("DELETE")
 
 	// Direct http handling to gorilla/mux router
 	http.Handle("/", rt)